@@ -0,0 +1,413 @@
+package easydb
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ArrayFormat 不支持数组类型的数据库中，数组的存储格式。postgres始终使用原生的数组类型
+type ArrayFormat int
+
+const (
+	// ArrayJSON JSON数组，如 ["a","b"], [1,2]。默认
+	ArrayJSON ArrayFormat = iota
+	// ArrayCSV 逗号分隔的文本，如 a,b。含逗号、双引号、换行或以空白开头的元素加双引号，
+	// 空数组为空文本，仅有一个空字符串元素的数组为 ""
+	ArrayCSV
+)
+
+// SetArrayFormat 设置不支持数组类型的数据库（如mysql, sqlite3）中，数组的存储格式，默认ArrayJSON。
+// 数组为元素是string, bool, 各类int, uint, float及以其为底层类型的切片，如[]string, []int64, []float64。
+// 此类结构体字段和参数在postgres中写入为原生数组（如text[], int[]），无需使用pq.Array；
+// 其他数据库中按format写入文本。读取时根据文本自动识别格式。
+// 作用于ExecInsert, ExecUpdateByValues, 结构体写入和查询构建器的列的值，以及Array包装的参数。
+// Exec, GetMany等方法的参数中，IN (...) 子句中的切片展开为多个占位符，其他位置的数组同样按format写入。
+func (d *EasyDb) SetArrayFormat(format ArrayFormat) {
+	d.arrayFormat = format
+}
+
+// Array 将切片作为单个数组参数，不展开为多个占位符。不在 IN (...) 子句中的数组无需包装，
+// 用于 IN (?) 之外无法自动识别的场合，以及元素类型不是数组元素的切片。如
+//
+//	d.GetMany("SELECT * FROM users WHERE id = ANY($1)", &users, easydb.Array(ids))
+//
+// 写入时按方言转换，见SetArrayFormat
+func Array(v interface{}) driver.Valuer {
+	return arrayArg{v: v}
+}
+
+// supportsArray 判断数据库是否支持数组类型
+func supportsArray(dl Dialect) bool {
+	return dl.Name() == "postgres"
+}
+
+// isArrayType 判断类型t是否为数组：元素为string, bool, 各类int, uint, float的切片，[]byte除外
+func isArrayType(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice {
+		return false
+	}
+	switch t.Elem().Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isArray 判断类型t是否为数组。元素类型注册了转换函数的切片不是数组，见isArrayType
+func (d *EasyDb) isArray(t reflect.Type) bool {
+	return isArrayType(t) && d.converters.byType[t.Elem()] == nil
+}
+
+// driverArgs 执行SQL语句前，转换传给驱动的参数：注册了编码函数的类型的值，以及未展开的数组
+func (d *EasyDb) driverArgs(args []interface{}) []interface{} {
+	return d.arrayArgs(d.encodeArgs(args))
+}
+
+// arrayArgs 将参数中未展开的数组（如列的值和Array包装的参数），替换为按方言格式化的driver.Valuer
+func (d *EasyDb) arrayArgs(args []interface{}) []interface{} {
+	var converted []interface{}
+	for i, arg := range args {
+		a, ok := arg.(arrayArg)
+		if !ok {
+			if _, isValuer := arg.(driver.Valuer); isValuer || arg == nil || !d.isArray(reflect.TypeOf(arg)) {
+				continue
+			}
+			a = arrayArg{v: arg}
+		}
+		a.native = supportsArray(d.dialect)
+		a.format = d.arrayFormat
+		if converted == nil {
+			converted = append([]interface{}(nil), args...)
+		}
+		converted[i] = a
+	}
+	if converted == nil {
+		return args
+	}
+	return converted
+}
+
+// arrayArg 数组参数。native为true时写入为postgres的数组，否则按format写入文本
+type arrayArg struct {
+	v      interface{}
+	format ArrayFormat
+	native bool
+}
+
+// Value 实现driver.Valuer接口。nil切片写入NULL
+func (a arrayArg) Value() (driver.Value, error) {
+	v := reflect.ValueOf(a.v)
+	if !v.IsValid() || !isArrayType(v.Type()) {
+		return nil, fmt.Errorf("不支持的数组类型%T", a.v)
+	}
+	if v.IsNil() {
+		return nil, nil
+	}
+	switch {
+	case a.native:
+		return formatPgArray(v), nil
+	case a.format == ArrayCSV:
+		return formatCSVArray(v)
+	}
+	return marshalJSON(a.v)
+}
+
+// String 用于日志输出
+func (a arrayArg) String() string {
+	return fmt.Sprint(a.v)
+}
+
+// arrayElemText 数组元素的文本
+func arrayElemText(e reflect.Value) string {
+	switch e.Kind() {
+	case reflect.String:
+		return e.String()
+	case reflect.Bool:
+		return strconv.FormatBool(e.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(e.Int(), 10)
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(e.Uint(), 10)
+	}
+	return strconv.FormatFloat(e.Float(), 'g', -1, e.Type().Bits())
+}
+
+var pgArrayEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// formatPgArray 格式化为postgres的数组字面量，如 {1,2}, {"a","b c"}。字符串元素均加双引号
+func formatPgArray(v reflect.Value) string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		e := v.Index(i)
+		if e.Kind() == reflect.String {
+			b.WriteByte('"')
+			pgArrayEscaper.WriteString(&b, e.String())
+			b.WriteByte('"')
+			continue
+		}
+		b.WriteString(arrayElemText(e))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatCSVArray 格式化为逗号分隔的文本。仅有一个空字符串元素时为 ""，以区别于空数组
+func formatCSVArray(v reflect.Value) (driver.Value, error) {
+	record := make([]string, v.Len())
+	for i := range record {
+		record[i] = arrayElemText(v.Index(i))
+	}
+	if len(record) == 1 && record[0] == "" {
+		return `""`, nil
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	if err := w.Write(record); err != nil {
+		return nil, fmt.Errorf("格式化数组失败: %w", err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("格式化数组失败: %w", err)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// parsePgArray 解析postgres的一维数组字面量，如 {1,2}, {"a","b c",NULL}, [0:1]={1,2}。NULL元素为nil
+func parsePgArray(text string) ([]*string, error) {
+	s := strings.TrimSpace(text)
+	if strings.HasPrefix(s, "[") {
+		// 指定了下标范围的数组
+		i := strings.Index(s, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("无法解析数组%q", text)
+		}
+		s = strings.TrimSpace(s[i+1:])
+	}
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("无法解析数组%q", text)
+	}
+	s = s[1 : len(s)-1]
+	elems := []*string{}
+	if strings.TrimSpace(s) == "" {
+		return elems, nil
+	}
+	for i := 0; ; {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		var b strings.Builder
+		quoted := i < len(s) && s[i] == '"'
+		if quoted {
+			i++
+		}
+		closed := false
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+				continue
+			}
+			if quoted {
+				if c == '"' {
+					i++
+					closed = true
+					break
+				}
+			} else if c == ',' {
+				break
+			} else if c == '{' {
+				return nil, fmt.Errorf("不支持多维数组%q", text)
+			}
+			b.WriteByte(c)
+		}
+		if quoted && !closed {
+			return nil, fmt.Errorf("无法解析数组%q", text)
+		}
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		elem := b.String()
+		if !quoted {
+			elem = strings.TrimSpace(elem)
+		}
+		if !quoted && strings.EqualFold(elem, "NULL") {
+			elems = append(elems, nil)
+		} else {
+			elems = append(elems, &elem)
+		}
+		if i >= len(s) {
+			return elems, nil
+		}
+		if s[i] != ',' {
+			return nil, fmt.Errorf("无法解析数组%q", text)
+		}
+		i++
+	}
+}
+
+// parseCSVArray 解析逗号分隔的文本。空文本为空数组
+func parseCSVArray(text string) ([]*string, error) {
+	elems := []*string{}
+	if text == "" {
+		return elems, nil
+	}
+	r := csv.NewReader(strings.NewReader(text))
+	record, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("无法解析数组%q: %w", text, err)
+	}
+	for i := range record {
+		elems = append(elems, &record[i])
+	}
+	return elems, nil
+}
+
+// setArrayElem 将数组元素的文本转换后写入e
+func setArrayElem(e reflect.Value, text string) error {
+	var err error
+	switch e.Kind() {
+	case reflect.String:
+		e.SetString(text)
+	case reflect.Bool:
+		var b bool
+		switch strings.ToLower(text) {
+		case "t", "true", "1":
+			b = true
+		case "f", "false", "0":
+		default:
+			err = fmt.Errorf("无法将%q转换为%s", text, e.Type())
+		}
+		e.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(text, 10, e.Type().Bits()); err == nil {
+			e.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(text, 10, e.Type().Bits()); err == nil {
+			e.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(text, e.Type().Bits()); err == nil {
+			e.SetFloat(f)
+		}
+	}
+	return err
+}
+
+// arrayScanner 扫描到数组。根据文本识别格式：postgres的数组字面量、JSON数组或逗号分隔的文本。
+// NULL为nil，NULL元素为零值
+type arrayScanner struct {
+	v reflect.Value
+}
+
+func (s arrayScanner) Scan(src interface{}) error {
+	if src == nil {
+		s.v.SetZero()
+		return nil
+	}
+	text := string(textValue(src))
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "[") && json.Valid([]byte(trimmed)) {
+		p := reflect.New(s.v.Type())
+		if err := json.Unmarshal([]byte(trimmed), p.Interface()); err != nil {
+			return fmt.Errorf("无法将%q转换为%s: %w", text, s.v.Type(), err)
+		}
+		s.v.Set(p.Elem())
+		return nil
+	}
+	var elems []*string
+	var err error
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		elems, err = parsePgArray(trimmed)
+	}
+	if elems == nil {
+		// 不是postgres的数组时，按逗号分隔的文本解析
+		if elems, err = parseCSVArray(text); err != nil {
+			return err
+		}
+	}
+	arr := reflect.MakeSlice(s.v.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		if elem == nil {
+			continue
+		}
+		if err = setArrayElem(arr.Index(i), *elem); err != nil {
+			return fmt.Errorf("无法将%q转换为%s: %w", text, s.v.Type(), err)
+		}
+	}
+	s.v.Set(arr)
+	return nil
+}
+
+// arrayColumnKind 获取postgres数组类型的列的元素的类别，如 _TEXT, _INT4。不是数组类型时返回false
+func arrayColumnKind(typeName string) (columnKind, bool) {
+	name, ok := strings.CutPrefix(typeName, "_")
+	if !ok {
+		if name, ok = strings.CutSuffix(typeName, "[]"); !ok {
+			return columnUnknown, false
+		}
+	}
+	return columnKinds[name], true
+}
+
+// decodeArray 解码postgres数组类型的列。按元素的类别解码为[]int64, []float64, []bool或[]string
+func decodeArray(value interface{}, kind columnKind, mode DecimalMode) (interface{}, error) {
+	var text string
+	switch v := value.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return value, nil
+	}
+	var t reflect.Type
+	switch {
+	case kind == columnInt:
+		t = reflect.TypeOf([]int64(nil))
+	case kind == columnFloat, kind == columnDecimal && mode == DecimalAsFloat64:
+		t = reflect.TypeOf([]float64(nil))
+	case kind == columnBool:
+		t = reflect.TypeOf([]bool(nil))
+	default:
+		t = reflect.TypeOf([]string(nil))
+	}
+	elems, err := parsePgArray(text)
+	if err != nil {
+		return nil, err
+	}
+	arr := reflect.MakeSlice(t, len(elems), len(elems))
+	for i, elem := range elems {
+		if elem == nil {
+			continue
+		}
+		if err := setArrayElem(arr.Index(i), *elem); err != nil {
+			return nil, err
+		}
+	}
+	return arr.Interface(), nil
+}
+
+// inClause 生成 column IN (?) 条件。postgres中values为非空的数组时，生成 column = ANY(?)，整个切片作为一个数组参数
+func (d *EasyDb) inClause(column string, values interface{}) sqlClause {
+	if supportsArray(d.dialect) && values != nil && d.isArray(reflect.TypeOf(values)) && reflect.ValueOf(values).Len() > 0 {
+		return sqlClause{text: column + " = ANY(?)", args: []interface{}{Array(values)}}
+	}
+	return sqlClause{text: column + " IN (?)", args: []interface{}{values}}
+}
@@ -0,0 +1,208 @@
+package easydb
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestPgArrayLiteral(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cases := []struct {
+		text string
+		want []*string
+	}{
+		{`{}`, []*string{}},
+		{`{1,2,3}`, []*string{str("1"), str("2"), str("3")}},
+		{`{"a","b c",NULL,"NULL"}`, []*string{str("a"), str("b c"), nil, str("NULL")}},
+		{`{"a\"b","c\\d", e }`, []*string{str(`a"b`), str(`c\d`), str("e")}},
+		{`[0:1]={t,f}`, []*string{str("t"), str("f")}},
+	}
+	for _, c := range cases {
+		got, err := parsePgArray(c.text)
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parsePgArray(%s) got: %v %v", c.text, got, err)
+		}
+	}
+	for _, text := range []string{`{{1,2},{3,4}}`, `{"a`, `1,2`} {
+		if _, err := parsePgArray(text); err == nil {
+			t.Errorf("parsePgArray(%s) should fail", text)
+		}
+	}
+	if got := formatPgArray(reflect.ValueOf([]string{"a", `b "c"`, `d\e`})); got != `{"a","b \"c\"","d\\e"}` {
+		t.Errorf("formatPgArray got: %s", got)
+	}
+	if got := formatPgArray(reflect.ValueOf([]float64{1.5, 2})); got != `{1.5,2}` {
+		t.Errorf("formatPgArray got: %s", got)
+	}
+}
+
+type arrayUser struct {
+	ID     int64     `db:"id,pk"`
+	Tags   []string  `db:"tags"`
+	Scores []float64 `db:"scores"`
+	Flags  []bool    `db:"flags"`
+}
+
+func TestArrayColumns(t *testing.T) {
+	result := &fakeResult{
+		columns: []string{"id", "tags", "scores", "flags"},
+		types:   []string{"INT8", "_TEXT", "_FLOAT8", "_BOOL"},
+		rows: [][]driver.Value{
+			{int64(1), []byte(`{"a","b c",NULL}`), `{1.5,2}`, `{t,f}`},
+			{int64(2), `["x","y"]`, `[3]`, `[true]`},
+			{int64(3), `x,"y,z"`, `4,5`, ``},
+			{int64(4), nil, nil, nil},
+		},
+	}
+	d := newFakeDb(t, result)
+	var users []arrayUser
+	if err := d.GetMany("SELECT ...", &users); err != nil {
+		t.Fatal(err)
+	}
+	want := []arrayUser{
+		{ID: 1, Tags: []string{"a", "b c", ""}, Scores: []float64{1.5, 2}, Flags: []bool{true, false}},
+		{ID: 2, Tags: []string{"x", "y"}, Scores: []float64{3}, Flags: []bool{true}},
+		{ID: 3, Tags: []string{"x", "y,z"}, Scores: []float64{4, 5}, Flags: []bool{}},
+		{ID: 4},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("GetMany got: %+v", users)
+	}
+
+	// postgres数组类型的列解码为对应类型的切片
+	result.rows = [][]driver.Value{result.rows[0], result.rows[3]}
+	var maps []map[string]interface{}
+	if err := d.GetMany("SELECT ...", &maps); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(maps[0]["tags"], []string{"a", "b c", ""}) || !reflect.DeepEqual(maps[0]["scores"], []float64{1.5, 2}) ||
+		!reflect.DeepEqual(maps[0]["flags"], []bool{true, false}) || maps[1]["tags"] != nil {
+		t.Errorf("GetMany map got: %+v", maps)
+	}
+
+	d2 := newFakeDb(t, &fakeResult{columns: []string{"ids"}, types: []string{"_INT4"}, rows: [][]driver.Value{{`{1,2}`}}})
+	var ids []int64
+	if err := d2.GetOneData("SELECT ids FROM t", &ids); err != nil || !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("GetOneData got: %v %v", ids, err)
+	}
+	var list [][]int64
+	if err := d2.GetMany("SELECT ids FROM t", &list); err != nil || !reflect.DeepEqual(list, [][]int64{{1, 2}}) {
+		t.Errorf("GetMany slice got: %v %v", list, err)
+	}
+}
+
+func TestArrayRoundTrip(t *testing.T) {
+	for _, format := range []ArrayFormat{ArrayJSON, ArrayCSV} {
+		for _, tags := range [][]string{{}, {""}, {"", ""}, {"", "a"}, {"a", "b,c", ` d`, `e"f`}} {
+			value, err := arrayArg{v: tags, format: format}.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			if err := (arrayScanner{v: reflect.ValueOf(&got).Elem()}).Scan(value); err != nil || !reflect.DeepEqual(got, tags) {
+				t.Errorf("format %d: %#v => %q => %#v %v", format, tags, value, got, err)
+			}
+		}
+	}
+}
+
+func TestArrayArgs(t *testing.T) {
+	result := &fakeResult{}
+	d := newFakeDb(t, result)
+	lastArgs := func() []driver.Value {
+		result.mu.Lock()
+		defer result.mu.Unlock()
+		return result.lastArgs
+	}
+	values := []interface{}{int64(1), []string{"a", "b,c"}, []int64(nil)}
+	formats := []struct {
+		dialect string
+		format  ArrayFormat
+		want    driver.Value
+	}{
+		{"postgres", ArrayCSV, `{"a","b,c"}`},
+		{"sqlite3", ArrayJSON, `["a","b,c"]`},
+		{"mysql", ArrayCSV, `a,"b,c"`},
+	}
+	for _, f := range formats {
+		d.SetDialect(GetDialect(f.dialect))
+		d.SetArrayFormat(f.format)
+		if err := d.ExecInsert("users", []string{"id", "tags", "ids"}, values); err != nil {
+			t.Fatal(err)
+		}
+		if want := []driver.Value{int64(1), f.want, nil}; !reflect.DeepEqual(lastArgs(), want) {
+			t.Errorf("%s ExecInsert args got: %#v", f.dialect, lastArgs())
+		}
+	}
+
+	d.SetDialect(GetDialect("postgres"))
+	if _, err := d.Exec("DELETE FROM users WHERE id = ANY(?)", Array([]int64{1, 2})); err != nil {
+		t.Fatal(err)
+	}
+	if want := []driver.Value{"{1,2}"}; !reflect.DeepEqual(lastArgs(), want) {
+		t.Errorf("Exec Array args got: %#v", lastArgs())
+	}
+	if _, err := d.Exec("DELETE FROM users WHERE id IN ($1)", []int64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []driver.Value{int64(1), int64(2)}; !reflect.DeepEqual(lastArgs(), want) {
+		t.Errorf("Exec slice args got: %#v", lastArgs())
+	}
+	if _, err := d.Exec("DELETE FROM users WHERE id NOT in($1)", []int64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []driver.Value{int64(1), int64(2)}; !reflect.DeepEqual(lastArgs(), want) {
+		t.Errorf("Exec NOT IN slice args got: %#v", lastArgs())
+	}
+
+	// 不在 IN (...) 中的数组作为单个参数
+	arrayCases := []struct {
+		query string
+		args  []interface{}
+		want  []driver.Value
+	}{
+		{"UPDATE users SET tags = $1 WHERE id = $2", []interface{}{[]string{"a", "b"}, 1}, []driver.Value{`{"a","b"}`, 1}},
+		{"DELETE FROM users WHERE id = ANY($1)", []interface{}{[]int64{1, 2}}, []driver.Value{"{1,2}"}},
+		{"UPDATE users SET tags = :tags WHERE id = :id", []interface{}{&arrayUser{ID: 1, Tags: []string{"a", "b"}}}, []driver.Value{`{"a","b"}`, int64(1)}},
+		{"UPDATE users SET tags = $1 WHERE id IN ($2)", []interface{}{[]string{"a"}, []int64{1, 2}}, []driver.Value{`{"a"}`, int64(1), int64(2)}},
+	}
+	for _, c := range arrayCases {
+		if _, err := d.Exec(c.query, c.args...); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(lastArgs(), c.want) {
+			t.Errorf("Exec(%s) args got: %#v", c.query, lastArgs())
+		}
+	}
+	if result.lastExec != "UPDATE users SET tags = $1 WHERE id IN ($2, $3)" {
+		t.Errorf("Exec array and IN got: %s", result.lastExec)
+	}
+
+	query, args, err := d.Select("id").From("users").WhereIn("id", []int64{1, 2}).Where("age > ?", 18).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT id FROM users WHERE (id = ANY($1)) AND (age > $2)"; query != want {
+		t.Errorf("WhereIn got: %s", query)
+	}
+	if v, err := args[0].(driver.Valuer).Value(); err != nil || v != "{1,2}" {
+		t.Errorf("WhereIn args got: %v %v", v, err)
+	}
+
+	d.SetDialect(GetDialect("mysql"))
+	query, args, err = d.Delete("users").WhereIn("id", []int64{1, 2}).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "DELETE FROM users WHERE id IN (?, ?)"; query != want || len(args) != 2 {
+		t.Errorf("WhereIn got: %s %v", query, args)
+	}
+
+	if _, err := d.Exec("UPDATE users SET tags = ? WHERE id IN (?)", []string{"a", "b"}, []int64{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []driver.Value{"a,b", int64(1), int64(2)}; result.lastExec != "UPDATE users SET tags = ? WHERE id IN (?, ?)" || !reflect.DeepEqual(lastArgs(), want) {
+		t.Errorf("mysql Exec array args got: %s %#v", result.lastExec, lastArgs())
+	}
+}
@@ -0,0 +1,217 @@
+package easydb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// batchSize 计算批量插入时单条语句插入的行数。受方言的最大参数数量和最大行数限制
+func (d *EasyDb) batchSize(columnCount int) (int, error) {
+	maxParams := d.maxParams
+	if maxParams <= 0 {
+		maxParams = d.dialect.MaxParams()
+	}
+	size := maxParams / columnCount
+	if size < 1 {
+		return 0, fmt.Errorf("列的数量(%d)超过单条语句的最大参数数量(%d)", columnCount, maxParams)
+	}
+	if maxRows := d.dialect.MaxInsertRows(); maxRows > 0 && size > maxRows {
+		size = maxRows
+	}
+	return size, nil
+}
+
+// ExecInsertBatch 批量插入数据，返回插入的总行数。
+// 生成多行 INSERT INTO ... VALUES (...), (...) 语句，按数据库的参数数量限制自动拆分为多条语句，
+// 如 postgres 65535, sqlite3 999, sqlserver 2100个参数且不超过1000行。拆分为多条语句时，在同一个事务中执行。
+// 示例：
+//
+//	affected, err := d.ExecInsertBatch("users", []string{"name", "age"}, [][]interface{}{
+//		{"Tom", 18},
+//		{"Ann", 20},
+//	})
+func (d *EasyDb) ExecInsertBatch(table string, columns []string, rows [][]interface{}) (int64, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.ExecInsertBatchContext(ctx, table, columns, rows)
+}
+
+// ExecInsertBatchContext 同ExecInsertBatch，使用ctx控制执行的取消和超时
+func (d *EasyDb) ExecInsertBatchContext(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("插入的列不能为空")
+	}
+	size, err := d.batchSize(len(columns))
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) <= size {
+		return d.execInsertBatch(ctx, d.db, table, columns, rows)
+	}
+	var total int64
+	err = d.WithTx(ctx, nil, func(tx *EasyTx) error {
+		var err error
+		total, err = d.execInsertBatch(ctx, tx.tx, table, columns, rows)
+		return err
+	})
+	return total, err
+}
+
+// execInsertBatch ExecInsertBatch的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) execInsertBatch(ctx context.Context, conn sqlConn, table string, columns []string, rows [][]interface{}) (int64, error) {
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("插入的列不能为空")
+	}
+	size, err := d.batchSize(len(columns))
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for start := 0; start < len(rows); start += size {
+		end := min(start+size, len(rows))
+		b := &InsertBuilder{writeBuilder: writeBuilder{db: d, conn: conn, table: table}, columns: columns, rows: rows[start:end]}
+		result, err := b.ExecContext(ctx)
+		if err != nil {
+			return total, fmt.Errorf("插入第%d至%d行数据失败: %w", start+1, end, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += affected
+	}
+	return total, nil
+}
+
+// structBatch 批量插入结构体切片时，插入的列相同的一组元素
+type structBatch struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// structBatchRows 获取批量插入结构体切片时的列和每行的值。字段的处理同InsertStruct：
+// 忽略readonly字段，单一主键和omitempty字段为零值时忽略，由数据库生成或使用默认值。
+// 各元素插入的列可能不同，插入的列相同的元素合并为一组。各组按其第一个元素的位置排列，组内保持元素的顺序
+func structBatchRows(src interface{}) ([]structBatch, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("参数必须是结构体切片，不支持%T", src)
+	}
+	elemType := v.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("参数必须是结构体切片，不支持%T", src)
+	}
+
+	fields := getStructFields(elemType)
+	pks := structPks(fields)
+	var batches []structBatch
+	// 插入的列 => batches中的序号
+	groups := make(map[string]int)
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i)
+		if isPtr {
+			if item.IsNil() {
+				return nil, fmt.Errorf("第%d个元素为nil", i+1)
+			}
+			item = item.Elem()
+		}
+		autoPk := len(pks) == 1 && pks[0].value(item).IsZero()
+		columns, values := structWriteColumns(item, fields, autoPk)
+		if len(columns) == 0 {
+			return nil, fmt.Errorf("结构体%s没有可插入的字段", elemType)
+		}
+		key := strings.Join(columns, ",")
+		if n, ok := groups[key]; ok {
+			batches[n].rows = append(batches[n].rows, values)
+			continue
+		}
+		groups[key] = len(batches)
+		batches = append(batches, structBatch{columns: columns, rows: [][]interface{}{values}})
+	}
+	return batches, nil
+}
+
+// insertStructBatches 依次插入各组数据，返回插入的总行数。conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) insertStructBatches(ctx context.Context, conn sqlConn, table string, batches []structBatch) (int64, error) {
+	var total int64
+	for _, b := range batches {
+		affected, err := d.execInsertBatch(ctx, conn, table, b.columns, b.rows)
+		total += affected
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// InsertStructs 根据结构体的db标签批量插入数据，返回插入的总行数。src为结构体切片，或结构体指针的切片。
+// 字段的处理同InsertStruct，数据库生成的主键不会回写到结构体。拆分语句的规则同ExecInsertBatch。
+// 单一主键或omitempty字段在部分元素中为零值时，各元素插入的列不同，按列分组插入，多条语句在同一事务中执行。
+// 分组插入时，不同组的元素的插入顺序可能与切片中的顺序不同。
+// 示例：
+//
+//	users := []User{{Name: "Tom", Age: 18}, {Name: "Ann", Age: 20}}
+//	affected, err := d.InsertStructs("users", users)
+func (d *EasyDb) InsertStructs(table string, src interface{}) (int64, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.InsertStructsContext(ctx, table, src)
+}
+
+// InsertStructsContext 同InsertStructs，使用ctx控制执行的取消和超时
+func (d *EasyDb) InsertStructsContext(ctx context.Context, table string, src interface{}) (int64, error) {
+	batches, err := structBatchRows(src)
+	if err != nil {
+		return 0, err
+	}
+	switch len(batches) {
+	case 0:
+		return 0, nil
+	case 1:
+		return d.ExecInsertBatchContext(ctx, table, batches[0].columns, batches[0].rows)
+	}
+	var total int64
+	err = d.WithTx(ctx, nil, func(tx *EasyTx) error {
+		var err error
+		total, err = d.insertStructBatches(ctx, tx.tx, table, batches)
+		return err
+	})
+	return total, err
+}
+
+// ExecInsertBatch 在事务中批量插入数据。用法同EasyDb.ExecInsertBatch
+func (t *EasyTx) ExecInsertBatch(table string, columns []string, rows [][]interface{}) (int64, error) {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.ExecInsertBatchContext(ctx, table, columns, rows)
+}
+
+// ExecInsertBatchContext 同ExecInsertBatch，使用ctx控制执行的取消和超时
+func (t *EasyTx) ExecInsertBatchContext(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	return t.db.execInsertBatch(ctx, t.tx, table, columns, rows)
+}
+
+// InsertStructs 在事务中根据结构体的db标签批量插入数据。用法同EasyDb.InsertStructs
+func (t *EasyTx) InsertStructs(table string, src interface{}) (int64, error) {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.InsertStructsContext(ctx, table, src)
+}
+
+// InsertStructsContext 同InsertStructs，使用ctx控制执行的取消和超时
+func (t *EasyTx) InsertStructsContext(ctx context.Context, table string, src interface{}) (int64, error) {
+	batches, err := structBatchRows(src)
+	if err != nil {
+		return 0, err
+	}
+	return t.db.insertStructBatches(ctx, t.tx, table, batches)
+}
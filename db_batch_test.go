@@ -0,0 +1,102 @@
+package easydb
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBatchSize(t *testing.T) {
+	tests := []struct {
+		driver    string
+		maxParams int
+		columns   int
+		want      int
+	}{
+		{"postgres", 0, 10, 6553},
+		{"sqlite3", 0, 10, 99},
+		{"sqlite3", 32766, 10, 3276},
+		{"sqlserver", 0, 1, 1000},
+		{"sqlserver", 0, 3, 699},
+	}
+	for _, tt := range tests {
+		d := &EasyDb{dialect: GetDialect(tt.driver), maxParams: tt.maxParams}
+		got, err := d.batchSize(tt.columns)
+		if err != nil || got != tt.want {
+			t.Errorf("batchSize(%s, %d) got: %d %v, want: %d", tt.driver, tt.columns, got, err, tt.want)
+		}
+	}
+	d := &EasyDb{dialect: GetDialect("sqlite3")}
+	if _, err := d.batchSize(1000); err == nil {
+		t.Error("batchSize should fail when columns exceed max params")
+	}
+}
+
+func TestStructBatchRows(t *testing.T) {
+	users := []*structTagUser{{Name: "Tom", Age: 18}, {Name: "Ann", Age: 20}}
+	batches, err := structBatchRows(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []structBatch{{columns: []string{"name", "age"}, rows: [][]interface{}{{"Tom", 18}, {"Ann", 20}}}}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("structBatchRows got: %+v", batches)
+	}
+
+	// 主键和omitempty字段在部分元素中为零值时，按插入的列分组，不写入零值
+	mixed := []structTagUser{{ID: 5, Name: "a"}, {Name: "b"}, {Name: "c", Age: 20}, {Name: "d", Age: 21}}
+	batches, err = structBatchRows(mixed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []structBatch{
+		{columns: []string{"id", "name"}, rows: [][]interface{}{{5, "a"}}},
+		{columns: []string{"name"}, rows: [][]interface{}{{"b"}}},
+		{columns: []string{"name", "age"}, rows: [][]interface{}{{"c", 20}, {"d", 21}}},
+	}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("structBatchRows mixed got: %+v", batches)
+	}
+
+	// 插入的列交替出现时，同样合并为一组
+	alternating := []structTagUser{{Name: "a"}, {Name: "b", Age: 20}, {Name: "c"}, {Name: "d", Age: 21}, {Name: "e"}}
+	batches, err = structBatchRows(alternating)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []structBatch{
+		{columns: []string{"name"}, rows: [][]interface{}{{"a"}, {"c"}, {"e"}}},
+		{columns: []string{"name", "age"}, rows: [][]interface{}{{"b", 20}, {"d", 21}}},
+	}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("structBatchRows alternating got: %+v", batches)
+	}
+	if _, err = structBatchRows(structTagUser{}); err == nil {
+		t.Error("structBatchRows should fail on non-slice")
+	}
+}
+
+func TestInsertStructsMixed(t *testing.T) {
+	result := &fakeResult{}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+	affected, err := d.InsertStructs("users", []structTagUser{{ID: 5, Name: "a"}, {Name: "b"}})
+	if err != nil || affected != 2 {
+		t.Fatalf("InsertStructs got: %d %v", affected, err)
+	}
+	want := []string{"BEGIN", "INSERT INTO users (id, name) VALUES ($1, $2)", "INSERT INTO users (name) VALUES ($1)", "COMMIT"}
+	if log := result.executed(); !reflect.DeepEqual(log, want) {
+		t.Errorf("InsertStructs executed: %q", log)
+	}
+
+	// 受影响的行数为插入的行数
+	result.rowsAffected = func(query string) int64 { return int64(strings.Count(query, "), (") + 1) }
+	affected, err = d.InsertStructs("users", []structTagUser{{Name: "a"}, {Name: "b", Age: 20}, {Name: "c"}, {Name: "d", Age: 21}})
+	if err != nil || affected != 4 {
+		t.Fatalf("InsertStructs got: %d %v", affected, err)
+	}
+	want = []string{"BEGIN", "INSERT INTO users (name) VALUES ($1), ($2)", "INSERT INTO users (name, age) VALUES ($1, $2), ($3, $4)", "COMMIT"}
+	if log := result.executed(); !reflect.DeepEqual(log, want) {
+		t.Errorf("InsertStructs alternating executed: %q", log)
+	}
+}
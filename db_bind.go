@@ -0,0 +1,470 @@
+package easydb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrEmptySlice 切片参数为空。IN (...) 子句不能为空
+var ErrEmptySlice = errors.New("切片参数不能为空")
+
+// sqlPart SQL语句解析后的片段：原样输出的文本，或参数占位符
+type sqlPart struct {
+	text  string
+	param bool
+	// 命名参数的名称
+	name string
+	// 参数在参数列表中的序号，从0开始
+	index int
+	// 参数为列的值，切片参数不展开
+	noExpand bool
+}
+
+// parseQuestion 解析使用?占位符的SQL语句。
+// 字符串、引号标识符和注释中的?不是占位符。??转义为字面量?，postgres的JSON操作符?|和?&保持不变。
+func parseQuestion(query, dialectName string) []sqlPart {
+	var parts []sqlPart
+	var text strings.Builder
+	n := 0
+	for i := 0; i < len(query); {
+		if j := skipLiteral(query, i, dialectName); j > i {
+			text.WriteString(query[i:j])
+			i = j
+			continue
+		}
+		if query[i] != '?' {
+			text.WriteByte(query[i])
+			i++
+			continue
+		}
+		next := byte(0)
+		if i+1 < len(query) {
+			next = query[i+1]
+		}
+		switch {
+		case next == '?':
+			// ?? 转义为字面量 ?
+			text.WriteByte('?')
+			i += 2
+		case next == '&', next == '|' && (i+2 >= len(query) || query[i+2] != '|'):
+			// postgres JSON操作符 ?| ?&。?|| 视为占位符后接字符串连接符
+			text.WriteString(query[i : i+2])
+			i += 2
+		default:
+			parts = append(parts, sqlPart{text: text.String()}, sqlPart{param: true, index: n})
+			text.Reset()
+			n++
+			i++
+		}
+	}
+	if text.Len() > 0 {
+		parts = append(parts, sqlPart{text: text.String()})
+	}
+	return parts
+}
+
+// parseNamed 解析使用 :name 或 @name 命名参数的SQL语句。
+// 字符串、引号标识符和注释中的内容不是命名参数。postgres的类型转换 ::type 保持不变。
+// sqlserver中 @name 为变量或参数，只有 :name 是命名参数；其他数据库中 @p1 等sqlserver风格的位置参数也不是命名参数。
+func parseNamed(query, dialectName string) []sqlPart {
+	var parts []sqlPart
+	var text strings.Builder
+	for i := 0; i < len(query); {
+		if j := skipLiteral(query, i, dialectName); j > i {
+			text.WriteString(query[i:j])
+			i = j
+			continue
+		}
+		c := query[i]
+		if c == ':' || (c == '@' && dialectName != "sqlserver") {
+			// ::type 类型转换、@@系统变量，连续的两个符号原样输出
+			if i+1 < len(query) && query[i+1] == c {
+				text.WriteString(query[i : i+2])
+				i += 2
+				continue
+			}
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) && query[j] != '$' {
+				j++
+			}
+			if j > i+1 && !isDigit(query[i+1]) && (i == 0 || !isIdentChar(query[i-1])) && !(c == '@' && isOrdinalName(query[i+1:j])) {
+				parts = append(parts, sqlPart{text: text.String()}, sqlPart{param: true, name: query[i+1 : j]})
+				text.Reset()
+				i = j
+				continue
+			}
+		}
+		text.WriteByte(c)
+		i++
+	}
+	if text.Len() > 0 {
+		parts = append(parts, sqlPart{text: text.String()})
+	}
+	return parts
+}
+
+// parseNative 解析使用方言原生占位符的SQL语句。
+// postgres: $1, sqlserver: @p1, oracle: :1，其他数据库: ?
+// start为语句中第一个占位符的序号，带序号的占位符对应的参数为第(序号-start)个，如start为2时，$3对应第1个参数
+func parseNative(query, dialectName string, start int) []sqlPart {
+	prefix := map[string]string{"postgres": "$", "sqlserver": "@p", "oracle": ":"}[dialectName]
+	var parts []sqlPart
+	var text strings.Builder
+	n := 0
+	for i := 0; i < len(query); {
+		if j := skipLiteral(query, i, dialectName); j > i {
+			text.WriteString(query[i:j])
+			i = j
+			continue
+		}
+		c := query[i]
+		param, index, j := false, 0, i+1
+		switch prefix {
+		case "":
+			if c == '?' {
+				param, index = true, n
+				n++
+			}
+		default:
+			if len(query)-i > len(prefix) && strings.EqualFold(query[i:i+len(prefix)], prefix) && (i == 0 || !isIdentChar(query[i-1])) {
+				j = i + len(prefix)
+				for j < len(query) && isDigit(query[j]) {
+					j++
+				}
+				if num, err := strconv.Atoi(query[i+len(prefix) : j]); err == nil && num > 0 {
+					// 序号小于等于start的占位符，index为负数，由bindParts返回错误
+					param, index = true, num-1-start
+				}
+			}
+		}
+		if !param {
+			text.WriteByte(c)
+			i++
+			continue
+		}
+		parts = append(parts, sqlPart{text: text.String()}, sqlPart{param: true, index: index})
+		text.Reset()
+		i = j
+	}
+	if text.Len() > 0 {
+		parts = append(parts, sqlPart{text: text.String()})
+	}
+	return parts
+}
+
+// isOrdinalName 判断名称是否为 p1, p2 等sqlserver风格的位置参数
+func isOrdinalName(name string) bool {
+	if len(name) < 2 || (name[0] != 'p' && name[0] != 'P') {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isDigit(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// countParams 统计解析后的SQL片段中的参数占位符数量
+func countParams(parts []sqlPart) int {
+	n := 0
+	for _, p := range parts {
+		if p.param {
+			n++
+		}
+	}
+	return n
+}
+
+// namedArg 判断参数是否为命名参数的值：仅有一个参数，且为键为字符串的map、结构体或结构体指针。
+// 实现了driver.Valuer接口的类型和time.Time，是普通的参数值。
+func namedArg(args []interface{}) (reflect.Value, bool) {
+	if len(args) != 1 || args[0] == nil {
+		return reflect.Value{}, false
+	}
+	if _, ok := args[0].(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+	if _, ok := args[0].(time.Time); ok {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(args[0])
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		return v, v.Type().Key().Kind() == reflect.String
+	case reflect.Struct:
+		return v, true
+	}
+	return reflect.Value{}, false
+}
+
+// namedValues 获取命名参数的值。结构体使用db标签作为参数名，嵌套的结构体字段使用 标签名_列名 作为参数名。
+func namedValues(arg reflect.Value) map[string]interface{} {
+	values := make(map[string]interface{})
+	if arg.Kind() == reflect.Map {
+		iter := arg.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = iter.Value().Interface()
+		}
+		return values
+	}
+	for name, f := range structColumns(arg.Type(), nil) {
+		values[name] = f.arg(arg)
+	}
+	return values
+}
+
+// bindNamed 将命名参数转换为方言对应的位置参数。start为第一个占位符的序号
+// SQL语句中没有命名参数，或同时使用了方言的位置参数时，返回错误，避免map或结构体参数被忽略。
+func bindNamed(dl Dialect, query string, arg reflect.Value, start int, emptyAsNull bool) (string, []interface{}, error) {
+	parts := parseNamed(query, dl.Name())
+	if countParams(parts) == 0 {
+		return "", nil, fmt.Errorf("SQL语句中没有命名参数，不能使用%s作为参数的值", arg.Type())
+	}
+	if countParams(parseNative(query, dl.Name(), 0)) > 0 {
+		return "", nil, fmt.Errorf("SQL语句中不能同时使用命名参数和位置参数(%s)", dl.Placeholder(0))
+	}
+	values := namedValues(arg)
+	used := make(map[string]bool)
+	var args []interface{}
+	for i, p := range parts {
+		if !p.param {
+			continue
+		}
+		v, ok := values[p.name]
+		if !ok {
+			return "", nil, fmt.Errorf("命名参数%s缺少对应的值", p.name)
+		}
+		used[p.name] = true
+		parts[i].index = len(args)
+		args = append(args, v)
+	}
+	// map中多余的键，通常是参数名拼写错误。结构体的字段则无需全部使用
+	if arg.Kind() == reflect.Map && len(used) < len(values) {
+		var unused []string
+		for name := range values {
+			if !used[name] {
+				unused = append(unused, name)
+			}
+		}
+		sort.Strings(unused)
+		return "", nil, fmt.Errorf("命名参数未在SQL语句中使用: %s", strings.Join(unused, ", "))
+	}
+	return bindParts(dl, parts, args, start, emptyAsNull)
+}
+
+// BindNamed 将使用 :name 或 @name 命名参数的SQL语句，转换为方言对应的位置参数的SQL语句和参数列表。
+// arg 为键为字符串的map，或使用db标签的结构体（指针）。
+// 示例：
+//
+//	query, args, err := easydb.BindNamed(easydb.GetDialect("postgres"), "SELECT * FROM users WHERE age > :min_age AND name = :name", map[string]any{"min_age": 18, "name": "Tom"})
+//	// SELECT * FROM users WHERE age > $1 AND name = $2    [18 Tom]
+func BindNamed(dl Dialect, query string, arg interface{}) (string, []interface{}, error) {
+	v, ok := namedArg([]interface{}{arg})
+	if !ok {
+		return "", nil, fmt.Errorf("命名参数的值必须是map[string]any或结构体，不支持%T", arg)
+	}
+	return bindNamed(dl, query, v, 0, false)
+}
+
+// skipLiteral 若query[i]是字符串、引号标识符或注释的开始，返回其结束位置，否则返回i
+func skipLiteral(query string, i int, dialectName string) int {
+	c := query[i]
+	next := byte(0)
+	if i+1 < len(query) {
+		next = query[i+1]
+	}
+	switch {
+	case c == '\'':
+		// mysql和postgres的E'...'字符串中，反斜杠为转义符
+		escape := dialectName == "mysql" || (i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') && (i == 1 || !isIdentChar(query[i-2])))
+		return skipQuoted(query, i, '\'', escape)
+	case c == '"':
+		return skipQuoted(query, i, '"', dialectName == "mysql")
+	case c == '`':
+		return skipQuoted(query, i, '`', false)
+	case c == '[' && dialectName == "sqlserver":
+		return skipQuoted(query, i, ']', false)
+	case c == '-' && next == '-', c == '#' && dialectName == "mysql":
+		if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+			return i + end + 1
+		}
+		return len(query)
+	case c == '/' && next == '*':
+		if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+			return i + 2 + end + 2
+		}
+		return len(query)
+	case c == '$' && dialectName == "postgres" && (i == 0 || !isIdentChar(query[i-1])):
+		// postgres的美元符号引用字符串 $$...$$ 或 $tag$...$tag$
+		j := i + 1
+		for j < len(query) && isIdentChar(query[j]) && query[j] != '$' {
+			j++
+		}
+		if j >= len(query) || query[j] != '$' || (j > i+1 && isDigit(query[i+1])) {
+			return i
+		}
+		tag := query[i : j+1]
+		if end := strings.Index(query[j+1:], tag); end >= 0 {
+			return j + 1 + end + len(tag)
+		}
+		return len(query)
+	}
+	return i
+}
+
+// skipQuoted 返回从query[i]开始的引号内容的结束位置。两个连续的结束引号视为转义。
+func skipQuoted(query string, i int, end byte, backslash bool) int {
+	for j := i + 1; j < len(query); j++ {
+		switch query[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case end:
+			if j+1 < len(query) && query[j+1] == end {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// buildSQL 将解析后的SQL片段拼接为使用方言占位符的SQL语句。start为第一个占位符的序号
+func buildSQL(dl Dialect, parts []sqlPart, start int) string {
+	var b strings.Builder
+	n := start
+	for _, p := range parts {
+		if !p.param {
+			b.WriteString(p.text)
+			continue
+		}
+		b.WriteString(dl.Placeholder(n))
+		n++
+	}
+	return b.String()
+}
+
+// bindParts 将解析后的SQL片段拼接为使用方言占位符的SQL语句，并按占位符的顺序排列参数。
+// 切片参数（[]byte除外）展开为多个占位符，用于 IN (...) 子句。空切片返回ErrEmptySlice错误，emptyAsNull为true时替换为NULL。
+// 占位符不在 IN (...) 中时，数组（见isArrayType）作为单个参数，不展开，如 SET tags = ?, id = ANY(?)
+func bindParts(dl Dialect, parts []sqlPart, args []interface{}, start int, emptyAsNull bool) (string, []interface{}, error) {
+	var b strings.Builder
+	bargs := make([]interface{}, 0, len(args))
+	for _, p := range parts {
+		if !p.param {
+			b.WriteString(p.text)
+			continue
+		}
+		if p.index >= len(args) {
+			return "", nil, fmt.Errorf("SQL语句中的占位符多于参数数量(%d)", len(args))
+		}
+		if p.index < 0 {
+			return "", nil, fmt.Errorf("SQL语句中的占位符序号应从%s开始", dl.Placeholder(start))
+		}
+		arg := args[p.index]
+		if p.noExpand || !isExpandable(arg) || (isArrayType(reflect.TypeOf(arg)) && !endsWithIn(b.String())) {
+			b.WriteString(dl.Placeholder(start + len(bargs)))
+			bargs = append(bargs, arg)
+			continue
+		}
+		v := reflect.ValueOf(arg)
+		if v.Len() == 0 {
+			if !emptyAsNull {
+				return "", nil, ErrEmptySlice
+			}
+			b.WriteString("NULL")
+			continue
+		}
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(dl.Placeholder(start + len(bargs)))
+			bargs = append(bargs, v.Index(i).Interface())
+		}
+	}
+	return b.String(), bargs, nil
+}
+
+// endsWithIn 判断SQL语句是否以 IN ( 结尾，即下一个占位符在 IN (...) 子句中。NOT IN ( 同样适用
+func endsWithIn(query string) bool {
+	query = strings.TrimRight(query, " \t\r\n")
+	if !strings.HasSuffix(query, "(") {
+		return false
+	}
+	query = strings.TrimRight(query[:len(query)-1], " \t\r\n")
+	n := len(query)
+	return n >= 2 && strings.EqualFold(query[n-2:], "IN") && (n == 2 || !isIdentChar(query[n-3]))
+}
+
+// isExpandable 判断参数是否为需要展开的切片。[]byte和实现了driver.Valuer接口的切片类型，是普通的参数值。
+func isExpandable(arg interface{}) bool {
+	if arg == nil {
+		return false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return false
+	}
+	t := reflect.TypeOf(arg)
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// hasExpandable 判断参数列表中是否有需要展开的切片
+func hasExpandable(args []interface{}) bool {
+	for _, arg := range args {
+		if isExpandable(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rebind 将使用?占位符的SQL语句，转换为方言对应的占位符。
+// 字符串、引号标识符和注释中的?保持不变。??转义为字面量?，postgres的JSON操作符?|和?&保持不变。
+// 示例：
+//
+//	easydb.Rebind(easydb.GetDialect("postgres"), "SELECT * FROM users WHERE name = ? AND age > ?")
+//	// SELECT * FROM users WHERE name = $1 AND age > $2
+func Rebind(dl Dialect, query string) string {
+	return buildSQL(dl, parseQuestion(query, dl.Name()), 0)
+}
+
+// bindQuery 执行SQL语句前，按EasyDb的设置处理SQL语句和参数。start为第一个占位符的序号
+// 仅有一个参数，且为map[string]any或结构体时，视为命名参数。IN (...) 子句中的切片展开为多个占位符，其他位置的数组作为单个参数。
+func (d *EasyDb) bindQuery(query string, args []interface{}, start int) (string, []interface{}, error) {
+	// 注册了转换函数的切片类型作为单个参数，不展开
+	args = d.encodeArgs(args)
+	if arg, ok := namedArg(args); ok {
+		return bindNamed(d.dialect, query, arg, start, d.emptySliceAsNull)
+	}
+	var parts []sqlPart
+	switch {
+	case d.rebind:
+		parts = parseQuestion(query, d.dialect.Name())
+	case hasExpandable(args):
+		parts = parseNative(query, d.dialect.Name(), start)
+	default:
+		return query, args, nil
+	}
+	return bindParts(d.dialect, parts, args, start, d.emptySliceAsNull)
+}
@@ -0,0 +1,161 @@
+package easydb
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	pg := GetDialect("postgres")
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE name = ? AND age > ?", "SELECT * FROM users WHERE name = $1 AND age > $2"},
+		{"SELECT '?' AS q, \"a?\" FROM t WHERE id = ?", "SELECT '?' AS q, \"a?\" FROM t WHERE id = $1"},
+		{"SELECT 'it''s ?' FROM t WHERE id = ?", "SELECT 'it''s ?' FROM t WHERE id = $1"},
+		{"SELECT id -- where ?\nFROM t /* ? */ WHERE id = ?", "SELECT id -- where ?\nFROM t /* ? */ WHERE id = $1"},
+		{"SELECT data ?? 'key' FROM t WHERE id = ?", "SELECT data ? 'key' FROM t WHERE id = $1"},
+		{"SELECT * FROM t WHERE tags ?| array['a'] AND tags ?& array['b'] AND id = ?", "SELECT * FROM t WHERE tags ?| array['a'] AND tags ?& array['b'] AND id = $1"},
+		{"SELECT ?|| 'x'", "SELECT $1|| 'x'"},
+		{"SELECT $$ ? $$, $tag$ ? $tag$, ?", "SELECT $$ ? $$, $tag$ ? $tag$, $1"},
+		{"SELECT E'\\' ?' , ?", "SELECT E'\\' ?' , $1"},
+	}
+	for _, c := range cases {
+		if got := Rebind(pg, c.query); got != c.want {
+			t.Errorf("Rebind(%q)\n got: %q\nwant: %q", c.query, got, c.want)
+		}
+	}
+	mysql := GetDialect("mysql")
+	if got := Rebind(mysql, "SELECT 'a\\'?' FROM t # ?\nWHERE id = ?"); got != "SELECT 'a\\'?' FROM t # ?\nWHERE id = ?" {
+		t.Errorf("mysql Rebind got: %q", got)
+	}
+	if got := Rebind(GetDialect("sqlserver"), "SELECT [a?] FROM t WHERE id = ?"); got != "SELECT [a?] FROM t WHERE id = @p1" {
+		t.Errorf("sqlserver Rebind got: %q", got)
+	}
+}
+
+func TestBindNamed(t *testing.T) {
+	pg := GetDialect("postgres")
+	query, args, err := BindNamed(pg, "SELECT id::text FROM users WHERE age > :min_age AND name = @name AND note <> ':skip' AND age < :min_age + 10", map[string]any{"min_age": 18, "name": "Tom"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT id::text FROM users WHERE age > $1 AND name = $2 AND note <> ':skip' AND age < $3 + 10"
+	if query != want || len(args) != 3 || args[0] != 18 || args[1] != "Tom" || args[2] != 18 {
+		t.Errorf("BindNamed\n got: %q %v\nwant: %q", query, args, want)
+	}
+
+	user := User{ID: 1, Name: "Tom", Age: 20}
+	query, args, err = BindNamed(GetDialect("mysql"), "UPDATE users SET age = :age WHERE id = :id", &user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "UPDATE users SET age = ? WHERE id = ?" || len(args) != 2 || args[0] != 20 || args[1] != 1 {
+		t.Errorf("BindNamed struct got: %q %v", query, args)
+	}
+
+	if _, _, err = BindNamed(pg, "SELECT * FROM users WHERE name = :name", map[string]any{}); err == nil {
+		t.Error("BindNamed should fail on missing name")
+	}
+	if _, _, err = BindNamed(pg, "SELECT * FROM users WHERE name = :name", map[string]any{"name": "Tom", "nmae": "Tom"}); err == nil {
+		t.Error("BindNamed should fail on unused name")
+	}
+
+	// 没有命名参数，或同时使用位置参数时，map和结构体参数不能被忽略
+	if _, _, err = BindNamed(GetDialect("mysql"), "SELECT id FROM users WHERE age > ?", &user); err == nil {
+		t.Error("BindNamed should fail on positional placeholders")
+	}
+	if _, _, err = BindNamed(pg, "SELECT id FROM users", map[string]any{"id": 1}); err == nil {
+		t.Error("BindNamed should fail without named parameters")
+	}
+	if _, _, err = BindNamed(pg, "SELECT id FROM users WHERE id = $1 AND name = :name", map[string]any{"name": "Tom"}); err == nil {
+		t.Error("BindNamed should fail on mixed placeholders")
+	}
+
+	// sqlserver中 @name 是变量，只有 :name 是命名参数
+	ms := GetDialect("sqlserver")
+	query, args, err = BindNamed(ms, "DECLARE @total INT; SELECT @total = COUNT(*) FROM users WHERE age > :min_age; SELECT @@ROWCOUNT, @total", map[string]any{"min_age": 18})
+	if want := "DECLARE @total INT; SELECT @total = COUNT(*) FROM users WHERE age > @p1; SELECT @@ROWCOUNT, @total"; err != nil || query != want || len(args) != 1 {
+		t.Errorf("BindNamed sqlserver got: %q %v %v", query, args, err)
+	}
+	if _, _, err = BindNamed(ms, "SELECT id FROM users WHERE age > @p1", map[string]any{"p1": 18}); err == nil {
+		t.Error("BindNamed should fail on sqlserver positional placeholders")
+	}
+	// 其他数据库中 @p1 也不是命名参数
+	if _, _, err = BindNamed(pg, "SELECT id FROM users WHERE age > @p1", map[string]any{"p1": 18}); err == nil {
+		t.Error("BindNamed should not treat @p1 as a name")
+	}
+
+	d := newFakeDb(t, &fakeResult{columns: []string{"id"}})
+	d.SetDialect(GetDialect("mysql"))
+	var ids []int64
+	if err = d.GetMany("SELECT id FROM users WHERE age > ?", &ids, user); err == nil {
+		t.Error("GetMany should fail when a struct is passed to positional placeholders")
+	}
+	if err = d.GetMany("SELECT id FROM users WHERE age > :age", &ids, user); err != nil {
+		t.Errorf("GetMany named got: %v", err)
+	}
+}
+
+func TestBindSlice(t *testing.T) {
+	d := &EasyDb{dialect: GetDialect("postgres")}
+	query, args, err := d.bindQuery("SELECT * FROM users WHERE id IN ($1) AND name = $2 AND data = $3", []any{[]int{1, 2, 3}, "Tom", []byte("x")}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT * FROM users WHERE id IN ($1, $2, $3) AND name = $4 AND data = $5" || len(args) != 5 || args[2] != 3 || args[3] != "Tom" {
+		t.Errorf("bindQuery postgres got: %q %v", query, args)
+	}
+
+	d = &EasyDb{dialect: GetDialect("mysql")}
+	query, args, err = d.bindQuery("SELECT * FROM users WHERE name = ? AND id IN (?)", []any{"Tom", []int64{1, 2}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT * FROM users WHERE name = ? AND id IN (?, ?)" || len(args) != 3 || args[2] != int64(2) {
+		t.Errorf("bindQuery mysql got: %q %v", query, args)
+	}
+
+	d = &EasyDb{dialect: GetDialect("postgres"), rebind: true}
+	query, args, err = d.bindQuery("SELECT * FROM users WHERE id IN (:ids) AND age > :age", []any{map[string]any{"ids": []string{"a", "b"}, "age": 1}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "SELECT * FROM users WHERE id IN ($1, $2) AND age > $3" || len(args) != 3 {
+		t.Errorf("bindQuery named got: %q %v", query, args)
+	}
+
+	if _, _, err = d.bindQuery("SELECT * FROM users WHERE id IN (?)", []any{[]int{}}, 0); err != ErrEmptySlice {
+		t.Errorf("bindQuery empty slice got: %v", err)
+	}
+	d.SetEmptySliceAsNull(true)
+	query, args, err = d.bindQuery("SELECT * FROM users WHERE id IN (?) AND age > ?", []any{[]int{}, 18}, 0)
+	if err != nil || query != "SELECT * FROM users WHERE id IN (NULL) AND age > $1" || len(args) != 1 {
+		t.Errorf("bindQuery empty slice as null got: %q %v %v", query, args, err)
+	}
+}
+
+func TestUpdateByValuesSliceWhere(t *testing.T) {
+	result := &fakeResult{}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+	// WHERE子句的占位符序号接在SET的列之后
+	err := d.ExecUpdateByValues("users", []string{"name", "age"}, []interface{}{"Tom", 18}, "id IN ($3) AND age > $4", []interface{}{[]int64{1, 2}, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.mu.Lock()
+	query, args := result.lastExec, result.lastArgs
+	result.mu.Unlock()
+	if want := "UPDATE users SET name = $1, age = $2 WHERE id IN ($3, $4) AND age > $5"; query != want {
+		t.Errorf("ExecUpdateByValues got: %s", query)
+	}
+	if want := []driver.Value{"Tom", 18, int64(1), int64(2), 10}; !reflect.DeepEqual(args, want) {
+		t.Errorf("ExecUpdateByValues args got: %#v", args)
+	}
+	if err = d.ExecUpdateByValues("users", []string{"name"}, []interface{}{"Tom"}, "id IN ($1)", []interface{}{[]int64{1, 2}}); err == nil {
+		t.Error("ExecUpdateByValues should fail when WHERE placeholders overlap SET columns")
+	}
+}
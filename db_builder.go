@@ -0,0 +1,350 @@
+package easydb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// sqlClause SQL子句及其参数。子句统一使用?占位符
+type sqlClause struct {
+	text string
+	args []interface{}
+}
+
+// partsWriter 拼接SQL子句，生成SQL片段和参数列表
+type partsWriter struct {
+	dialectName string
+	parts       []sqlPart
+	args        []interface{}
+}
+
+func (w *partsWriter) text(s string) {
+	w.parts = append(w.parts, sqlPart{text: s})
+}
+
+// value 写入列的值的占位符。切片作为列的值，不展开
+func (w *partsWriter) value(v interface{}) {
+	w.parts = append(w.parts, sqlPart{param: true, index: len(w.args), noExpand: true})
+	w.args = append(w.args, v)
+}
+
+// clause 写入使用?占位符的子句。参数为*SelectBuilder时，作为子查询加括号写入。
+func (w *partsWriter) clause(c sqlClause) error {
+	parts := parseQuestion(c.text, w.dialectName)
+	count := 0
+	for _, p := range parts {
+		if p.param {
+			count++
+		}
+	}
+	if count != len(c.args) {
+		return fmt.Errorf("子句(%s)中的占位符数量(%d)与参数数量(%d)不一致", c.text, count, len(c.args))
+	}
+	for _, p := range parts {
+		if !p.param {
+			w.parts = append(w.parts, p)
+			continue
+		}
+		arg := c.args[p.index]
+		if sub, ok := arg.(*SelectBuilder); ok {
+			subParts, subArgs, err := sub.build()
+			if err != nil {
+				return err
+			}
+			w.text("(")
+			for _, sp := range subParts {
+				if sp.param {
+					sp.index += len(w.args)
+				}
+				w.parts = append(w.parts, sp)
+			}
+			w.args = append(w.args, subArgs...)
+			w.text(")")
+			continue
+		}
+		w.parts = append(w.parts, sqlPart{param: true, index: len(w.args)})
+		w.args = append(w.args, arg)
+	}
+	return nil
+}
+
+// clauses 写入多个子句，以sep连接。多于一个子句时，每个子句加括号。
+func (w *partsWriter) clauses(list []sqlClause, sep string) error {
+	for i, c := range list {
+		if i > 0 {
+			w.text(sep)
+		}
+		if len(list) > 1 {
+			c.text = "(" + c.text + ")"
+		}
+		if err := w.clause(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bindBuilt 将构建器生成的SQL片段和参数，转换为使用方言占位符的SQL语句和参数列表。
+// 子句中的切片参数展开为多个占位符，未展开的列的值中的数组按方言转换
+func (d *EasyDb) bindBuilt(parts []sqlPart, args []interface{}) (string, []interface{}, error) {
+	query, bargs, err := bindParts(d.dialect, parts, d.encodeArgs(args), 0, d.emptySliceAsNull)
+	if err != nil {
+		return "", nil, err
+	}
+	return query, d.arrayArgs(bargs), nil
+}
+
+// isZeroArg 判断参数是否为零值。nil、零值和空的切片、map均视为零值
+func isZeroArg(arg interface{}) bool {
+	if arg == nil {
+		return true
+	}
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// SelectBuilder SELECT语句构建器。各子句统一使用?占位符，生成SQL时转换为方言对应的占位符。
+// 使用EasyDb.Select或EasyTx.Select创建。
+// 示例：
+//
+//	var users []User
+//	err := d.Select("id", "name", "age", "wallet_balance").From("users").
+//		Where("age > ?", 18).
+//		WhereNotZero("name = ?", name). // name为空字符串时，忽略此条件
+//		OrderBy("id DESC").Limit(10).
+//		GetMany(&users)
+type SelectBuilder struct {
+	db       *EasyDb
+	conn     sqlConn
+	distinct bool
+	columns  []string
+	from     *sqlClause
+	joins    []sqlClause
+	wheres   []sqlClause
+	groupBy  []string
+	havings  []sqlClause
+	orderBy  []string
+	limit    int
+	offset   int
+}
+
+// Select 创建SELECT语句构建器。不传columns时查询所有列(*)
+func (d *EasyDb) Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{db: d, conn: d.db, columns: columns}
+}
+
+// Select 创建在事务中执行的SELECT语句构建器。不传columns时查询所有列(*)
+func (t *EasyTx) Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{db: t.db, conn: t.tx, columns: columns}
+}
+
+// Distinct 去除重复的行
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// Columns 追加查询的列
+func (b *SelectBuilder) Columns(columns ...string) *SelectBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// From 设置查询的表。可以使用子查询，如 From("? AS t", subBuilder)
+func (b *SelectBuilder) From(table string, args ...interface{}) *SelectBuilder {
+	b.from = &sqlClause{text: table, args: args}
+	return b
+}
+
+// Join 添加完整的JOIN子句，如 Join("LEFT JOIN orders o ON o.user_id = u.id AND o.status = ?", 1)
+func (b *SelectBuilder) Join(join string, args ...interface{}) *SelectBuilder {
+	b.joins = append(b.joins, sqlClause{text: join, args: args})
+	return b
+}
+
+// InnerJoin 添加 INNER JOIN table ON on 子句
+func (b *SelectBuilder) InnerJoin(table, on string, args ...interface{}) *SelectBuilder {
+	return b.Join("INNER JOIN "+table+" ON "+on, args...)
+}
+
+// LeftJoin 添加 LEFT JOIN table ON on 子句
+func (b *SelectBuilder) LeftJoin(table, on string, args ...interface{}) *SelectBuilder {
+	return b.Join("LEFT JOIN "+table+" ON "+on, args...)
+}
+
+// RightJoin 添加 RIGHT JOIN table ON on 子句
+func (b *SelectBuilder) RightJoin(table, on string, args ...interface{}) *SelectBuilder {
+	return b.Join("RIGHT JOIN "+table+" ON "+on, args...)
+}
+
+// Where 添加WHERE条件，多个条件以AND连接。
+// 切片参数展开为多个占位符，如 Where("id IN (?)", []int{1, 2, 3})。
+// *SelectBuilder参数作为子查询加括号写入，如 Where("id IN ?", subBuilder)
+func (b *SelectBuilder) Where(cond string, args ...interface{}) *SelectBuilder {
+	b.wheres = append(b.wheres, sqlClause{text: cond, args: args})
+	return b
+}
+
+// WhereIn 添加 column IN (...) 条件，values为切片。
+// postgres中values为非空的数组（如[]int64, []string）时，生成 column = ANY(?)，整个切片作为一个数组参数，不受参数数量的限制
+func (b *SelectBuilder) WhereIn(column string, values interface{}) *SelectBuilder {
+	b.wheres = append(b.wheres, b.db.inClause(column, values))
+	return b
+}
+
+// WhereNotZero 添加可选的WHERE条件。arg为零值（nil、0、""、空切片等）时，忽略此条件。
+// 适用于根据用户输入动态拼接的查询条件，如 WhereNotZero("name = ?", name)
+func (b *SelectBuilder) WhereNotZero(cond string, arg interface{}) *SelectBuilder {
+	if isZeroArg(arg) {
+		return b
+	}
+	return b.Where(cond, arg)
+}
+
+// GroupBy 设置分组的列
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// Having 添加HAVING条件，多个条件以AND连接
+func (b *SelectBuilder) Having(cond string, args ...interface{}) *SelectBuilder {
+	b.havings = append(b.havings, sqlClause{text: cond, args: args})
+	return b
+}
+
+// OrderBy 设置排序，如 OrderBy("age DESC", "id")
+func (b *SelectBuilder) OrderBy(columns ...string) *SelectBuilder {
+	b.orderBy = append(b.orderBy, columns...)
+	return b
+}
+
+// Limit 限制返回的行数
+func (b *SelectBuilder) Limit(limit int) *SelectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Offset 跳过的行数
+func (b *SelectBuilder) Offset(offset int) *SelectBuilder {
+	b.offset = offset
+	return b
+}
+
+// build 生成SQL片段和参数列表
+func (b *SelectBuilder) build() ([]sqlPart, []interface{}, error) {
+	dl := b.db.dialect
+	w := &partsWriter{dialectName: dl.Name()}
+	w.text("SELECT ")
+	if b.distinct {
+		w.text("DISTINCT ")
+	}
+	if len(b.columns) == 0 {
+		w.text("*")
+	} else {
+		w.text(strings.Join(b.columns, ", "))
+	}
+	if b.from != nil {
+		w.text(" FROM ")
+		if err := w.clause(*b.from); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, j := range b.joins {
+		w.text(" ")
+		if err := w.clause(j); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(b.wheres) > 0 {
+		w.text(" WHERE ")
+		if err := w.clauses(b.wheres, " AND "); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(b.groupBy) > 0 {
+		w.text(" GROUP BY " + strings.Join(b.groupBy, ", "))
+	}
+	if len(b.havings) > 0 {
+		w.text(" HAVING ")
+		if err := w.clauses(b.havings, " AND "); err != nil {
+			return nil, nil, err
+		}
+	}
+	orderBy := b.orderBy
+	limitOffset := dl.LimitOffset(b.limit, b.offset)
+	if limitOffset != "" && len(orderBy) == 0 && dl.Name() == "sqlserver" {
+		// SQL Server的分页语法必须有ORDER BY子句
+		orderBy = []string{"(SELECT NULL)"}
+	}
+	if len(orderBy) > 0 {
+		w.text(" ORDER BY " + strings.Join(orderBy, ", "))
+	}
+	if limitOffset != "" {
+		w.text(" " + limitOffset)
+	}
+	return w.parts, w.args, nil
+}
+
+// ToSQL 生成使用方言占位符的SQL语句和参数列表
+func (b *SelectBuilder) ToSQL() (string, []interface{}, error) {
+	parts, args, err := b.build()
+	if err != nil {
+		return "", nil, err
+	}
+	return b.db.bindBuilt(parts, args)
+}
+
+// GetMany 执行查询，结果扫描到dest切片的指针。用法同EasyDb.GetMany
+func (b *SelectBuilder) GetMany(dest interface{}) error {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.GetManyContext(ctx, dest)
+}
+
+// GetManyContext 同GetMany，使用ctx控制查询的取消和超时
+func (b *SelectBuilder) GetManyContext(ctx context.Context, dest interface{}) error {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return err
+	}
+	return b.db.getManyBound(ctx, b.conn, query, dest, args...)
+}
+
+// GetOneData 执行查询，首行结果扫描到结构体指针或map。用法同EasyDb.GetOneData
+func (b *SelectBuilder) GetOneData(dest interface{}) error {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.GetOneDataContext(ctx, dest)
+}
+
+// GetOneDataContext 同GetOneData，使用ctx控制查询的取消和超时
+func (b *SelectBuilder) GetOneDataContext(ctx context.Context, dest interface{}) error {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return err
+	}
+	return b.db.getOneDataBound(ctx, b.conn, query, dest, args...)
+}
+
+// GetOne 执行查询，首行结果按列的顺序扫描到dest。用法同EasyDb.GetOne
+func (b *SelectBuilder) GetOne(dest []interface{}) error {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.GetOneContext(ctx, dest)
+}
+
+// GetOneContext 同GetOne，使用ctx控制查询的取消和超时
+func (b *SelectBuilder) GetOneContext(ctx context.Context, dest []interface{}) error {
+	query, args, err := b.ToSQL()
+	if err != nil {
+		return err
+	}
+	return b.db.getOneBound(ctx, b.conn, query, dest, args...)
+}
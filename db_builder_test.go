@@ -0,0 +1,88 @@
+package easydb
+
+import (
+	"testing"
+)
+
+func TestSelectBuilder(t *testing.T) {
+	d := &EasyDb{dialect: GetDialect("postgres")}
+	sub := d.Select("user_id").From("orders").Where("amount > ?", 100)
+	query, args, err := d.Select("u.id", "u.name", "COUNT(o.id) AS total").From("users u").
+		LeftJoin("orders o", "o.user_id = u.id AND o.status = ?", 1).
+		Where("u.age > ? OR u.vip = ?", 18, true).
+		Where("u.id IN (?)", []int{1, 2}).
+		Where("u.id IN ?", sub).
+		WhereNotZero("u.name = ?", "").
+		WhereNotZero("u.city = ?", "Beijing").
+		GroupBy("u.id", "u.name").
+		Having("COUNT(o.id) > ?", 2).
+		OrderBy("total DESC").Limit(10).Offset(20).
+		ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "SELECT u.id, u.name, COUNT(o.id) AS total FROM users u LEFT JOIN orders o ON o.user_id = u.id AND o.status = $1" +
+		" WHERE (u.age > $2 OR u.vip = $3) AND (u.id IN ($4, $5)) AND (u.id IN (SELECT user_id FROM orders WHERE amount > $6)) AND (u.city = $7)" +
+		" GROUP BY u.id, u.name HAVING COUNT(o.id) > $8 ORDER BY total DESC LIMIT 10 OFFSET 20"
+	if query != want {
+		t.Errorf("SelectBuilder\n got: %s\nwant: %s", query, want)
+	}
+	if len(args) != 8 || args[5] != 100 || args[6] != "Beijing" || args[7] != 2 {
+		t.Errorf("SelectBuilder args got: %v", args)
+	}
+
+	d = &EasyDb{dialect: GetDialect("sqlserver")}
+	query, _, err = d.Select().From("? AS t", d.Select("id").From("users").Where("age > ?", 1)).Limit(5).ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "SELECT * FROM (SELECT id FROM users WHERE age > @p1) AS t ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY"
+	if query != want {
+		t.Errorf("SelectBuilder sqlserver\n got: %s\nwant: %s", query, want)
+	}
+
+	if _, _, err = d.Select().From("users").Where("age > ? AND id = ?", 1).ToSQL(); err == nil {
+		t.Error("SelectBuilder should fail on placeholder count mismatch")
+	}
+}
+
+func TestWriteBuilder(t *testing.T) {
+	pg := &EasyDb{dialect: GetDialect("postgres")}
+	query, args, err := pg.Insert("users").Columns("name", "tags").Values("Tom", []string{"a", "b"}).Values("Ann", nil).Returning("id").ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "INSERT INTO users (name, tags) VALUES ($1, $2), ($3, $4) RETURNING id" || len(args) != 4 {
+		t.Errorf("InsertBuilder got: %s %v", query, args)
+	}
+
+	query, args, err = pg.Update("users").Set("name", "Tom").SetExpr("age = age + ?", 1).Where("id IN (?)", []int{1, 2}).Returning("id", "age").ToSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "UPDATE users SET name = $1, age = age + $2 WHERE id IN ($3, $4) RETURNING id, age" || len(args) != 4 {
+		t.Errorf("UpdateBuilder got: %s %v", query, args)
+	}
+
+	ms := &EasyDb{dialect: GetDialect("sqlserver")}
+	query, _, err = ms.Insert("users").Set("name", "Tom").Returning("id").ToSQL()
+	if err != nil || query != "INSERT INTO users (name) OUTPUT INSERTED.id VALUES (@p1)" {
+		t.Errorf("InsertBuilder sqlserver got: %s %v", query, err)
+	}
+	query, _, err = ms.Delete("users").Where("id = ?", 1).Returning("id").ToSQL()
+	if err != nil || query != "DELETE FROM users OUTPUT DELETED.id WHERE id = @p1" {
+		t.Errorf("DeleteBuilder sqlserver got: %s %v", query, err)
+	}
+
+	my := &EasyDb{dialect: GetDialect("mysql")}
+	if _, _, err = my.Delete("users").Where("id = ?", 1).Returning("id").ToSQL(); err == nil {
+		t.Error("DeleteBuilder mysql should not support RETURNING")
+	}
+
+	ora := &EasyDb{dialect: GetDialect("oracle")}
+	query, _, err = ora.Insert("users").Columns("name", "age").Values("Tom", 18).Values("Ann", 20).ToSQL()
+	want := "INSERT ALL INTO users (name, age) VALUES (:1, :2) INTO users (name, age) VALUES (:3, :4) SELECT 1 FROM DUAL"
+	if err != nil || query != want {
+		t.Errorf("InsertBuilder oracle got: %s %v", query, err)
+	}
+}
@@ -0,0 +1,414 @@
+package easydb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// returningClause 生成返回列子句。
+// postgres, sqlite3 使用语句末尾的 RETURNING 子句。SQL Server 使用 OUTPUT 子句，prefix为INSERTED或DELETED，output为true。
+func returningClause(dl Dialect, columns []string, prefix string) (clause string, output bool, err error) {
+	if len(columns) == 0 {
+		return "", false, nil
+	}
+	if dl.Name() == "sqlserver" {
+		cols := make([]string, len(columns))
+		for i, col := range columns {
+			cols[i] = prefix + "." + col
+		}
+		return "OUTPUT " + strings.Join(cols, ", "), true, nil
+	}
+	if !dl.SupportsReturning() {
+		return "", false, fmt.Errorf("数据库%s不支持RETURNING子句", dl.Name())
+	}
+	return "RETURNING " + strings.Join(columns, ", "), false, nil
+}
+
+// writeBuilder INSERT/UPDATE/DELETE语句构建器的公共部分
+type writeBuilder struct {
+	db        *EasyDb
+	conn      sqlConn
+	table     string
+	returning []string
+}
+
+// exec 执行SQL语句
+func (b *writeBuilder) exec(ctx context.Context, build func() (string, []interface{}, error)) (sql.Result, error) {
+	query, args, err := build()
+	if err != nil {
+		return nil, err
+	}
+	return b.db.execBound(ctx, b.conn, query, args...)
+}
+
+// execReturning 执行带返回列的SQL语句，dest为切片的指针时接收所有行，否则只接收首行
+func (b *writeBuilder) execReturning(ctx context.Context, build func() (string, []interface{}, error), dest interface{}) error {
+	if len(b.returning) == 0 {
+		return fmt.Errorf("请先调用Returning方法设置返回的列")
+	}
+	query, args, err := build()
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice {
+		return b.db.getManyBound(ctx, b.conn, query, dest, args...)
+	}
+	return b.db.getOneDataBound(ctx, b.conn, query, dest, args...)
+}
+
+// InsertBuilder INSERT语句构建器。使用EasyDb.Insert或EasyTx.Insert创建。
+// 示例：
+//
+//	result, err := d.Insert("users").Columns("name", "age").Values("Tom", 18).Exec()
+//	id, err := result.LastInsertId() // mysql, sqlite3
+//
+//	// postgres, sqlite3 使用RETURNING子句，SQL Server使用OUTPUT子句获取生成的列
+//	var user User
+//	err = d.Insert("users").Set("name", "Tom").Set("age", 18).Returning("id", "name", "age", "wallet_balance").ExecReturning(&user)
+type InsertBuilder struct {
+	writeBuilder
+	columns []string
+	rows    [][]interface{}
+}
+
+// Insert 创建INSERT语句构建器
+func (d *EasyDb) Insert(table string) *InsertBuilder {
+	return &InsertBuilder{writeBuilder: writeBuilder{db: d, conn: d.db, table: table}}
+}
+
+// Insert 创建在事务中执行的INSERT语句构建器
+func (t *EasyTx) Insert(table string) *InsertBuilder {
+	return &InsertBuilder{writeBuilder: writeBuilder{db: t.db, conn: t.tx, table: table}}
+}
+
+// Columns 设置插入的列
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// Values 添加一行数据，值的顺序与Columns一致。多次调用插入多行
+func (b *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// Set 设置单行数据中一列的值。不能与插入多行的Values同时使用
+func (b *InsertBuilder) Set(column string, value interface{}) *InsertBuilder {
+	b.columns = append(b.columns, column)
+	if len(b.rows) == 0 {
+		b.rows = append(b.rows, nil)
+	}
+	b.rows[0] = append(b.rows[0], value)
+	return b
+}
+
+// Returning 设置执行后返回的列
+func (b *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+// ToSQL 生成使用方言占位符的SQL语句和参数列表
+func (b *InsertBuilder) ToSQL() (string, []interface{}, error) {
+	if len(b.columns) == 0 || len(b.rows) == 0 {
+		return "", nil, fmt.Errorf("插入的列和数据不能为空")
+	}
+	dl := b.db.dialect
+	returning, output, err := returningClause(dl, b.returning, "INSERTED")
+	if err != nil {
+		return "", nil, err
+	}
+	w := &partsWriter{dialectName: dl.Name()}
+	into := fmt.Sprintf("INTO %s (%s)", b.table, strings.Join(b.columns, ", "))
+	// Oracle不支持多行VALUES，使用 INSERT ALL INTO ... VALUES (...) INTO ... VALUES (...) SELECT 1 FROM DUAL
+	insertAll := dl.Name() == "oracle" && len(b.rows) > 1
+	if insertAll {
+		w.text("INSERT ALL")
+	} else {
+		w.text("INSERT " + into)
+	}
+	if output {
+		w.text(" " + returning)
+	}
+	if !insertAll {
+		w.text(" VALUES ")
+	}
+	for i, row := range b.rows {
+		if len(row) != len(b.columns) {
+			return "", nil, fmt.Errorf("第%d行数据的数量(%d)与列的数量(%d)不一致", i+1, len(row), len(b.columns))
+		}
+		if insertAll {
+			w.text(" " + into + " VALUES ")
+		} else if i > 0 {
+			w.text(", ")
+		}
+		w.text("(")
+		for j, v := range row {
+			if j > 0 {
+				w.text(", ")
+			}
+			w.value(v)
+		}
+		w.text(")")
+	}
+	if insertAll {
+		w.text(" SELECT 1 FROM DUAL")
+	}
+	if returning != "" && !output {
+		w.text(" " + returning)
+	}
+	return b.db.bindBuilt(w.parts, w.args)
+}
+
+// Exec 执行插入。可通过sql.Result获取LastInsertId和RowsAffected
+func (b *InsertBuilder) Exec() (sql.Result, error) {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.ExecContext(ctx)
+}
+
+// ExecContext 同Exec，使用ctx控制执行的取消和超时
+func (b *InsertBuilder) ExecContext(ctx context.Context) (sql.Result, error) {
+	return b.exec(ctx, b.ToSQL)
+}
+
+// ExecReturning 执行插入，Returning设置的列扫描到dest。
+// dest为结构体指针或map时接收首行，为切片的指针时接收所有行
+func (b *InsertBuilder) ExecReturning(dest interface{}) error {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.ExecReturningContext(ctx, dest)
+}
+
+// ExecReturningContext 同ExecReturning，使用ctx控制执行的取消和超时
+func (b *InsertBuilder) ExecReturningContext(ctx context.Context, dest interface{}) error {
+	return b.execReturning(ctx, b.ToSQL, dest)
+}
+
+// UpdateBuilder UPDATE语句构建器。使用EasyDb.Update或EasyTx.Update创建。
+// 示例：
+//
+//	result, err := d.Update("users").Set("name", "Tom").SetExpr("age = age + ?", 1).Where("id = ?", 1).Exec()
+//	affected, err := result.RowsAffected()
+type UpdateBuilder struct {
+	writeBuilder
+	sets   []updateSet
+	wheres []sqlClause
+}
+
+// updateSet SET子句中的一项。expr不为nil时为表达式，否则为列的值
+type updateSet struct {
+	column string
+	value  interface{}
+	expr   *sqlClause
+}
+
+// Update 创建UPDATE语句构建器
+func (d *EasyDb) Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{writeBuilder: writeBuilder{db: d, conn: d.db, table: table}}
+}
+
+// Update 创建在事务中执行的UPDATE语句构建器
+func (t *EasyTx) Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{writeBuilder: writeBuilder{db: t.db, conn: t.tx, table: table}}
+}
+
+// Set 设置列的值
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b.sets = append(b.sets, updateSet{column: column, value: value})
+	return b
+}
+
+// SetMap 设置多列的值，按列名排序
+func (b *UpdateBuilder) SetMap(values map[string]interface{}) *UpdateBuilder {
+	columns := make([]string, 0, len(values))
+	for col := range values {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+	for _, col := range columns {
+		b.Set(col, values[col])
+	}
+	return b
+}
+
+// SetExpr 使用表达式设置列的值，如 SetExpr("age = age + ?", 1)
+func (b *UpdateBuilder) SetExpr(expr string, args ...interface{}) *UpdateBuilder {
+	b.sets = append(b.sets, updateSet{expr: &sqlClause{text: expr, args: args}})
+	return b
+}
+
+// Where 添加WHERE条件，多个条件以AND连接。用法同SelectBuilder.Where
+func (b *UpdateBuilder) Where(cond string, args ...interface{}) *UpdateBuilder {
+	b.wheres = append(b.wheres, sqlClause{text: cond, args: args})
+	return b
+}
+
+// WhereIn 添加 column IN (...) 条件。用法同SelectBuilder.WhereIn
+func (b *UpdateBuilder) WhereIn(column string, values interface{}) *UpdateBuilder {
+	b.wheres = append(b.wheres, b.db.inClause(column, values))
+	return b
+}
+
+// Returning 设置执行后返回的列
+func (b *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+// ToSQL 生成使用方言占位符的SQL语句和参数列表
+func (b *UpdateBuilder) ToSQL() (string, []interface{}, error) {
+	if len(b.sets) == 0 {
+		return "", nil, fmt.Errorf("更新的列不能为空")
+	}
+	dl := b.db.dialect
+	returning, output, err := returningClause(dl, b.returning, "INSERTED")
+	if err != nil {
+		return "", nil, err
+	}
+	w := &partsWriter{dialectName: dl.Name()}
+	w.text(fmt.Sprintf("UPDATE %s SET ", b.table))
+	for i, s := range b.sets {
+		if i > 0 {
+			w.text(", ")
+		}
+		if s.expr != nil {
+			if err := w.clause(*s.expr); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+		w.text(s.column + " = ")
+		w.value(s.value)
+	}
+	if output {
+		w.text(" " + returning)
+	}
+	if len(b.wheres) > 0 {
+		w.text(" WHERE ")
+		if err := w.clauses(b.wheres, " AND "); err != nil {
+			return "", nil, err
+		}
+	}
+	if returning != "" && !output {
+		w.text(" " + returning)
+	}
+	return b.db.bindBuilt(w.parts, w.args)
+}
+
+// Exec 执行更新。可通过sql.Result获取RowsAffected
+func (b *UpdateBuilder) Exec() (sql.Result, error) {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.ExecContext(ctx)
+}
+
+// ExecContext 同Exec，使用ctx控制执行的取消和超时
+func (b *UpdateBuilder) ExecContext(ctx context.Context) (sql.Result, error) {
+	return b.exec(ctx, b.ToSQL)
+}
+
+// ExecReturning 执行更新，Returning设置的列扫描到dest。
+// dest为结构体指针或map时接收首行，为切片的指针时接收所有行
+func (b *UpdateBuilder) ExecReturning(dest interface{}) error {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.ExecReturningContext(ctx, dest)
+}
+
+// ExecReturningContext 同ExecReturning，使用ctx控制执行的取消和超时
+func (b *UpdateBuilder) ExecReturningContext(ctx context.Context, dest interface{}) error {
+	return b.execReturning(ctx, b.ToSQL, dest)
+}
+
+// DeleteBuilder DELETE语句构建器。使用EasyDb.Delete或EasyTx.Delete创建。
+// 示例：
+//
+//	result, err := d.Delete("users").Where("id IN (?)", []int{1, 2}).Exec()
+type DeleteBuilder struct {
+	writeBuilder
+	wheres []sqlClause
+}
+
+// Delete 创建DELETE语句构建器
+func (d *EasyDb) Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{writeBuilder: writeBuilder{db: d, conn: d.db, table: table}}
+}
+
+// Delete 创建在事务中执行的DELETE语句构建器
+func (t *EasyTx) Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{writeBuilder: writeBuilder{db: t.db, conn: t.tx, table: table}}
+}
+
+// Where 添加WHERE条件，多个条件以AND连接。用法同SelectBuilder.Where
+func (b *DeleteBuilder) Where(cond string, args ...interface{}) *DeleteBuilder {
+	b.wheres = append(b.wheres, sqlClause{text: cond, args: args})
+	return b
+}
+
+// WhereIn 添加 column IN (...) 条件。用法同SelectBuilder.WhereIn
+func (b *DeleteBuilder) WhereIn(column string, values interface{}) *DeleteBuilder {
+	b.wheres = append(b.wheres, b.db.inClause(column, values))
+	return b
+}
+
+// Returning 设置执行后返回的列，即被删除的数据
+func (b *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	b.returning = append(b.returning, columns...)
+	return b
+}
+
+// ToSQL 生成使用方言占位符的SQL语句和参数列表
+func (b *DeleteBuilder) ToSQL() (string, []interface{}, error) {
+	dl := b.db.dialect
+	returning, output, err := returningClause(dl, b.returning, "DELETED")
+	if err != nil {
+		return "", nil, err
+	}
+	w := &partsWriter{dialectName: dl.Name()}
+	w.text("DELETE FROM " + b.table)
+	if output {
+		w.text(" " + returning)
+	}
+	if len(b.wheres) > 0 {
+		w.text(" WHERE ")
+		if err := w.clauses(b.wheres, " AND "); err != nil {
+			return "", nil, err
+		}
+	}
+	if returning != "" && !output {
+		w.text(" " + returning)
+	}
+	return b.db.bindBuilt(w.parts, w.args)
+}
+
+// Exec 执行删除。可通过sql.Result获取RowsAffected
+func (b *DeleteBuilder) Exec() (sql.Result, error) {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.ExecContext(ctx)
+}
+
+// ExecContext 同Exec，使用ctx控制执行的取消和超时
+func (b *DeleteBuilder) ExecContext(ctx context.Context) (sql.Result, error) {
+	return b.exec(ctx, b.ToSQL)
+}
+
+// ExecReturning 执行删除，Returning设置的列扫描到dest。
+// dest为结构体指针或map时接收首行，为切片的指针时接收所有行
+func (b *DeleteBuilder) ExecReturning(dest interface{}) error {
+	ctx, cancel := b.db.newContext()
+	defer cancel()
+	return b.ExecReturningContext(ctx, dest)
+}
+
+// ExecReturningContext 同ExecReturning，使用ctx控制执行的取消和超时
+func (b *DeleteBuilder) ExecReturningContext(ctx context.Context, dest interface{}) error {
+	return b.execReturning(ctx, b.ToSQL, dest)
+}
@@ -0,0 +1,205 @@
+package easydb
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Converter 自定义类型与数据库的值之间的转换函数，使用EasyDb.RegisterConverter注册
+type Converter struct {
+	// Encode 将Go值转换为写入数据库的值，作用于Exec, ExecInsert, ExecUpdateByValues, 结构体写入和查询构建器等的参数。
+	// value为注册的类型的值，不为nil。为nil时不转换
+	Encode func(value interface{}) (driver.Value, error)
+	// Decode 将驱动返回的值转换为注册的类型的值，作用于结构体字段、GetMany的切片元素和GetOneData, GetOne的dest。
+	// value不为nil，返回值须可转换为注册的类型。为nil时不转换
+	Decode func(value interface{}) (interface{}, error)
+}
+
+// converters 按Go类型和数据库类型注册的转换函数
+type converters struct {
+	// byType Go类型对应的转换函数。内层的key为大写的数据库类型名称，空字符串表示不限列类型
+	byType map[reflect.Type]map[string]Converter
+	// byColumn 限定了数据库类型的解码函数，同时用于map[string]interface{}和interface{}接收的结果
+	byColumn map[string]Converter
+}
+
+// RegisterConverter 注册自定义类型的转换函数。sample为该类型的值，如 Money(0), Status("")。
+// columnTypes为空时作用于该类型的所有列；不为空时，Decode仅作用于这些数据库类型（ColumnType.DatabaseTypeName()，不区分大小写）的列，
+// 并且map[string]interface{}和interface{}接收的结果中，这些数据库类型的列同样使用Decode解码。
+// 自定义类型的底层类型不能是结构体，结构体类型请实现sql.Scanner和driver.Valuer接口。
+// 示例：
+//
+//	type Money int64 // 单位为分，数据库中为DECIMAL(10,2)
+//	d.RegisterConverter(Money(0), easydb.Converter{
+//		Encode: func(v interface{}) (driver.Value, error) {
+//			return fmt.Sprintf("%.2f", float64(v.(Money))/100), nil
+//		},
+//		Decode: func(v interface{}) (interface{}, error) {
+//			f, err := strconv.ParseFloat(fmt.Sprint(v), 64)
+//			return Money(math.Round(f * 100)), err
+//		},
+//	})
+func (d *EasyDb) RegisterConverter(sample interface{}, conv Converter, columnTypes ...string) {
+	t := reflect.TypeOf(sample)
+	if t == nil || t.Kind() == reflect.Struct {
+		panic(fmt.Sprintf("RegisterConverter不支持%T，结构体类型请实现sql.Scanner和driver.Valuer接口", sample))
+	}
+	if d.converters.byType == nil {
+		d.converters.byType = make(map[reflect.Type]map[string]Converter)
+		d.converters.byColumn = make(map[string]Converter)
+	}
+	convs := d.converters.byType[t]
+	if convs == nil {
+		convs = make(map[string]Converter)
+		d.converters.byType[t] = convs
+	}
+	if len(columnTypes) == 0 {
+		convs[""] = conv
+		return
+	}
+	for _, columnType := range columnTypes {
+		columnType = strings.ToUpper(columnType)
+		convs[columnType] = conv
+		if conv.Decode != nil {
+			d.converters.byColumn[columnType] = conv
+		}
+	}
+}
+
+// decodeFunc 单列的自定义解码函数
+type decodeFunc func(value interface{}) (interface{}, error)
+
+// typeDecoder 获取类型t在数据库类型为columnType的列上的解码函数。没有注册时返回nil
+func (d *EasyDb) typeDecoder(t reflect.Type, columnType string) decodeFunc {
+	convs := d.converters.byType[t]
+	if convs == nil {
+		return nil
+	}
+	if conv, ok := convs[strings.ToUpper(columnType)]; ok && conv.Decode != nil {
+		return conv.Decode
+	}
+	if conv, ok := convs[""]; ok && conv.Decode != nil {
+		return conv.Decode
+	}
+	return nil
+}
+
+// typeDecoders 获取查询结果各列的自定义解码函数。types为各列的接收变量的类型，可为nil。没有注册转换函数时返回nil
+func (d *EasyDb) typeDecoders(rows *sql.Rows, types []reflect.Type) ([]decodeFunc, error) {
+	if len(d.converters.byType) == 0 {
+		return nil, nil
+	}
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("获取列类型失败: %w", err)
+	}
+	decs := make([]decodeFunc, len(types))
+	for i, t := range types {
+		if t != nil && i < len(cols) {
+			decs[i] = d.typeDecoder(t, cols[i].DatabaseTypeName())
+		}
+	}
+	return decs, nil
+}
+
+// decodeScanner 使用自定义解码函数扫描到接收变量v
+type decodeScanner struct {
+	v           reflect.Value
+	decode      decodeFunc
+	nullAsError bool
+}
+
+func (s decodeScanner) Scan(src interface{}) error {
+	if src == nil {
+		if s.nullAsError {
+			return fmt.Errorf("无法将NULL转换为%s", s.v.Type())
+		}
+		s.v.SetZero()
+		return nil
+	}
+	if b, ok := src.([]byte); ok {
+		// 驱动返回的[]byte在下次扫描时可能被覆盖
+		src = bytes.Clone(b)
+	}
+	val, err := s.decode(src)
+	if err != nil {
+		return fmt.Errorf("解码为%s失败: %w", s.v.Type(), err)
+	}
+	rv := reflect.ValueOf(val)
+	switch {
+	case !rv.IsValid():
+		s.v.SetZero()
+	case rv.Type().AssignableTo(s.v.Type()):
+		s.v.Set(rv)
+	case rv.Type().ConvertibleTo(s.v.Type()):
+		s.v.Set(rv.Convert(s.v.Type()))
+	default:
+		return fmt.Errorf("解码函数返回的%T无法转换为%s", val, s.v.Type())
+	}
+	return nil
+}
+
+// encodedValue 使用自定义编码函数写入数据库的参数
+type encodedValue struct {
+	value  interface{}
+	encode func(value interface{}) (driver.Value, error)
+}
+
+// Value 实现driver.Valuer接口
+func (v encodedValue) Value() (driver.Value, error) {
+	return v.encode(v.value)
+}
+
+// String 用于日志输出
+func (v encodedValue) String() string {
+	return fmt.Sprint(v.value)
+}
+
+// typeEncoder 获取类型t的编码函数。没有注册时返回nil
+func (d *EasyDb) typeEncoder(t reflect.Type) func(value interface{}) (driver.Value, error) {
+	convs := d.converters.byType[t]
+	if conv, ok := convs[""]; ok && conv.Encode != nil {
+		return conv.Encode
+	}
+	for _, conv := range convs {
+		if conv.Encode != nil {
+			return conv.Encode
+		}
+	}
+	return nil
+}
+
+// encodeArgs 将参数中注册了编码函数的类型的值（包括其非nil的指针），替换为调用编码函数的driver.Valuer
+func (d *EasyDb) encodeArgs(args []interface{}) []interface{} {
+	if len(d.converters.byType) == 0 {
+		return args
+	}
+	var encoded []interface{}
+	for i, arg := range args {
+		if arg == nil {
+			continue
+		}
+		t := reflect.TypeOf(arg)
+		if t.Kind() == reflect.Ptr {
+			if v := reflect.ValueOf(arg); !v.IsNil() && d.typeEncoder(t.Elem()) != nil {
+				arg, t = v.Elem().Interface(), t.Elem()
+			}
+		}
+		encode := d.typeEncoder(t)
+		if encode == nil {
+			continue
+		}
+		if encoded == nil {
+			encoded = append([]interface{}(nil), args...)
+		}
+		encoded[i] = encodedValue{value: arg, encode: encode}
+	}
+	if encoded == nil {
+		return args
+	}
+	return encoded
+}
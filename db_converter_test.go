@@ -0,0 +1,112 @@
+package easydb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// convMoney 金额，单位为分，数据库中为DECIMAL
+type convMoney int64
+
+// convTags 数据库中为逗号分隔的文本
+type convTags []string
+
+type convOrder struct {
+	ID    int64     `db:"id"`
+	Price convMoney `db:"price"`
+	Tags  convTags  `db:"tags"`
+	Code  convMoney `db:"code"`
+}
+
+func registerTestConverters(d *EasyDb) {
+	d.RegisterConverter(convMoney(0), Converter{
+		Encode: func(v interface{}) (driver.Value, error) {
+			m := v.(convMoney)
+			return fmt.Sprintf("%d.%02d", m/100, m%100), nil
+		},
+		Decode: func(v interface{}) (interface{}, error) {
+			f, err := strconv.ParseFloat(fmt.Sprintf("%s", v), 64)
+			return convMoney(f*100 + 0.5), err
+		},
+	}, "DECIMAL")
+	d.RegisterConverter(convTags(nil), Converter{
+		Encode: func(v interface{}) (driver.Value, error) {
+			return strings.Join(v.(convTags), ","), nil
+		},
+		Decode: func(v interface{}) (interface{}, error) {
+			return strings.Split(fmt.Sprintf("%s", v), ","), nil
+		},
+	})
+}
+
+func TestConverter(t *testing.T) {
+	result := &fakeResult{
+		columns: []string{"id", "price", "tags", "code"},
+		types:   []string{"INT", "DECIMAL", "TEXT", "INT"},
+		rows:    [][]driver.Value{{int64(1), []byte("12.34"), "a,b", int64(7)}},
+	}
+	d := newFakeDb(t, result)
+	registerTestConverters(d)
+
+	var orders []convOrder
+	if err := d.GetMany("SELECT ...", &orders); err != nil {
+		t.Fatal(err)
+	}
+	// code列为INT，不使用限定DECIMAL的解码函数
+	want := convOrder{ID: 1, Price: 1234, Tags: convTags{"a", "b"}, Code: 7}
+	if len(orders) != 1 || !reflect.DeepEqual(orders[0], want) {
+		t.Errorf("GetMany got: %+v", orders)
+	}
+	data := make(map[string]interface{})
+	if err := d.GetOneData("SELECT ...", data); err != nil {
+		t.Fatal(err)
+	}
+	if data["price"] != convMoney(1234) || data["tags"] != "a,b" {
+		t.Errorf("GetOneData map got: %#v", data)
+	}
+
+	d2 := newFakeDb(t, &fakeResult{columns: []string{"tags"}, rows: [][]driver.Value{{"x,y"}}})
+	registerTestConverters(d2)
+	var tags convTags
+	if err := d2.GetOneData("SELECT tags", &tags); err != nil || !reflect.DeepEqual(tags, convTags{"x", "y"}) {
+		t.Errorf("GetOneData scalar got: %v %v", tags, err)
+	}
+	var list []convTags
+	if err := d2.GetMany("SELECT tags", &list); err != nil || len(list) != 1 || !reflect.DeepEqual(list[0], convTags{"x", "y"}) {
+		t.Errorf("GetMany scalar got: %v %v", list, err)
+	}
+
+	price := convMoney(505)
+	checkArgs := func(name string, want ...driver.Value) {
+		t.Helper()
+		result.mu.Lock()
+		defer result.mu.Unlock()
+		if !reflect.DeepEqual(result.lastArgs, want) {
+			t.Errorf("%s args got: %#v, want: %#v", name, result.lastArgs, want)
+		}
+	}
+	if _, err := d.Exec("UPDATE orders SET price = $1, tags = $2", &price, convTags{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	checkArgs("Exec", "5.05", "a,b")
+	if err := d.ExecInsert("orders", []string{"price", "tags"}, []interface{}{price, convTags{"c"}}); err != nil {
+		t.Fatal(err)
+	}
+	checkArgs("ExecInsert", "5.05", "c")
+	if err := d.ExecUpdateByValues("orders", []string{"price"}, []interface{}{price}, "id = $2", []interface{}{int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	checkArgs("ExecUpdateByValues", "5.05", int64(1))
+	if _, err := d.Insert("orders").Columns("price").Values(convMoney(1)).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	checkArgs("InsertBuilder", "0.01")
+	if err := d.InsertStruct("orders", &convOrder{Price: 100, Tags: convTags{"d"}}); err != nil {
+		t.Fatal(err)
+	}
+	checkArgs("InsertStruct", int64(0), "1.00", "d", "0.00")
+}
@@ -0,0 +1,319 @@
+package easydb
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ColumnDecoder 自定义列值的解码函数，用于map[string]interface{}和interface{}接收的查询结果。
+// value为驱动返回的值，不为nil；col为该列的类型信息
+type ColumnDecoder func(value interface{}, col *sql.ColumnType) (interface{}, error)
+
+// DecimalMode DECIMAL, NUMERIC等定点数类型的列的解码方式
+type DecimalMode int
+
+const (
+	// DecimalAsFloat64 解码为float64，默认。可能损失精度
+	DecimalAsFloat64 DecimalMode = iota
+	// DecimalAsString 保留为字符串，如"12.30"
+	DecimalAsString
+	// DecimalAsBigRat 解码为*big.Rat
+	DecimalAsBigRat
+)
+
+// SetDecoder 设置数据库类型typeName的列的解码函数，用于map[string]interface{}和interface{}接收的查询结果。
+// typeName为ColumnType.DatabaseTypeName()的值，不区分大小写，如 NUMERIC, UUID。fn为nil时取消设置。
+// 示例：
+//
+//	d.SetDecoder("NUMERIC", func(value interface{}, col *sql.ColumnType) (interface{}, error) {
+//		return decimal.NewFromString(fmt.Sprint(value))
+//	})
+func (d *EasyDb) SetDecoder(typeName string, fn ColumnDecoder) {
+	typeName = strings.ToUpper(typeName)
+	if fn == nil {
+		delete(d.decoders, typeName)
+		return
+	}
+	if d.decoders == nil {
+		d.decoders = make(map[string]ColumnDecoder)
+	}
+	d.decoders[typeName] = fn
+}
+
+// SetDecimalMode 设置DECIMAL, NUMERIC等定点数类型的列的解码方式，用于map[string]interface{}和interface{}接收的查询结果。
+// 默认DecimalAsFloat64。注意部分驱动（如sqlite3）返回的已是float64，保留为字符串时无法还原原始的小数位数。
+func (d *EasyDb) SetDecimalMode(mode DecimalMode) {
+	d.decimalMode = mode
+}
+
+// columnKind 根据数据库类型划分的列的类别
+type columnKind int
+
+const (
+	columnUnknown columnKind = iota
+	columnInt
+	columnUint
+	columnFloat
+	columnDecimal
+	columnBool
+	columnString
+	columnBytes
+	columnTime
+	columnJSON
+)
+
+var columnKinds = map[string]columnKind{}
+
+func init() {
+	for kind, names := range map[columnKind][]string{
+		columnInt:     {"TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "INT2", "INT4", "INT8", "SERIAL", "SMALLSERIAL", "BIGSERIAL", "YEAR"},
+		columnFloat:   {"REAL", "FLOAT", "DOUBLE", "FLOAT4", "FLOAT8", "DOUBLE PRECISION", "BINARY_FLOAT", "BINARY_DOUBLE"},
+		columnDecimal: {"DECIMAL", "NUMERIC", "NUMBER", "DEC"},
+		columnBool:    {"BOOL", "BOOLEAN", "BIT"},
+		columnString: {"CHAR", "VARCHAR", "TEXT", "NCHAR", "NVARCHAR", "NTEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT",
+			"VARCHAR2", "NVARCHAR2", "CLOB", "NCLOB", "BPCHAR", "CHARACTER", "CHARACTER VARYING", "CITEXT", "NAME",
+			"UUID", "ENUM", "SET", "XML", "INET", "CIDR", "MACADDR", "INTERVAL", "TIME", "TIMETZ", "MONEY", "SMALLMONEY"},
+		columnBytes: {"BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BYTEA", "IMAGE", "RAW", "LONG RAW"},
+		columnTime: {"DATE", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET", "TIMESTAMP", "TIMESTAMPTZ",
+			"TIMESTAMP WITH TIME ZONE", "TIMESTAMP WITHOUT TIME ZONE"},
+		columnJSON: {"JSON", "JSONB"},
+	} {
+		for _, name := range names {
+			columnKinds[name] = kind
+		}
+	}
+}
+
+// columnKindOf 获取列的类别。优先按数据库类型名称判断，如 VARCHAR(50), INT UNSIGNED；无法判断时按ScanType判断。
+// MONEY等带货币格式的类型保留为字符串；BIT仅在为单个位时解码为bool
+func columnKindOf(col *sql.ColumnType) columnKind {
+	name := strings.ToUpper(col.DatabaseTypeName())
+	size := ""
+	if i := strings.IndexByte(name, '('); i >= 0 {
+		j := strings.LastIndexByte(name, ')')
+		if j < i {
+			j = len(name) - 1
+		}
+		size = strings.TrimSpace(name[i+1 : j])
+		name = name[:i] + name[j+1:]
+	}
+	name = strings.TrimSpace(name)
+	if name == "BIT" {
+		if size != "" && size != "1" {
+			return columnString
+		}
+		if length, ok := col.Length(); ok && length > 1 {
+			return columnString
+		}
+	}
+	unsigned := false
+	if strings.HasSuffix(name, " UNSIGNED") || strings.HasPrefix(name, "UNSIGNED ") {
+		unsigned = true
+		name = strings.TrimSpace(strings.Replace(name, "UNSIGNED", "", 1))
+	}
+	kind, ok := columnKinds[name]
+	if !ok {
+		kind = scanTypeKind(col.ScanType())
+	}
+	if kind == columnInt && unsigned {
+		return columnUint
+	}
+	if kind == columnDecimal {
+		// 小数位数为0的定点数，如oracle的NUMBER(10)
+		if precision, scale, ok := col.DecimalSize(); ok && scale == 0 && precision > 0 && precision <= 18 {
+			return columnInt
+		}
+	}
+	return kind
+}
+
+// scanTypeKind 根据驱动提供的ScanType获取列的类别
+func scanTypeKind(t reflect.Type) columnKind {
+	if t == nil {
+		return columnUnknown
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType, reflect.TypeOf(sql.NullTime{}):
+		return columnTime
+	case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}), reflect.TypeOf(sql.NullByte{}):
+		return columnInt
+	case reflect.TypeOf(sql.NullFloat64{}):
+		return columnFloat
+	case reflect.TypeOf(sql.NullBool{}):
+		return columnBool
+	case reflect.TypeOf(sql.NullString{}):
+		return columnString
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return columnInt
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return columnUint
+	case reflect.Float32, reflect.Float64:
+		return columnFloat
+	case reflect.Bool:
+		return columnBool
+	case reflect.String:
+		return columnString
+	}
+	return columnUnknown
+}
+
+// columnDecode 单列的解码函数
+type columnDecode func(value interface{}) (interface{}, error)
+
+// columnDecoders 根据查询结果各列的类型，获取各列的解码函数
+func (d *EasyDb) columnDecoders(rows *sql.Rows) ([]columnDecode, error) {
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("获取列类型失败: %w", err)
+	}
+	decs := make([]columnDecode, len(cols))
+	for i, col := range cols {
+		decs[i] = d.columnDecoder(col)
+	}
+	return decs, nil
+}
+
+func (d *EasyDb) columnDecoder(col *sql.ColumnType) columnDecode {
+	typeName := strings.ToUpper(col.DatabaseTypeName())
+	if fn, ok := d.decoders[typeName]; ok {
+		return func(value interface{}) (interface{}, error) {
+			return fn(value, col)
+		}
+	}
+	if conv, ok := d.converters.byColumn[typeName]; ok {
+		return conv.Decode
+	}
+	mode := d.decimalMode
+	if elemKind, ok := arrayColumnKind(typeName); ok {
+		return func(value interface{}) (interface{}, error) {
+			v, err := decodeArray(value, elemKind, mode)
+			if err != nil {
+				return nil, fmt.Errorf("解码列%s的值失败: %w", col.Name(), err)
+			}
+			return v, nil
+		}
+	}
+	kind := columnKindOf(col)
+	return func(value interface{}) (interface{}, error) {
+		return decodeColumn(value, kind, mode), nil
+	}
+}
+
+// decodeRow 使用各列的解码函数解码一行数据。NULL为nil
+func decodeRow(decs []columnDecode, values []interface{}) error {
+	for i, dec := range decs {
+		p := values[i].(*interface{})
+		if *p == nil {
+			continue
+		}
+		v, err := dec(*p)
+		if err != nil {
+			return err
+		}
+		*p = v
+	}
+	return nil
+}
+
+// decodeColumn 按列的类别解码驱动返回的值。驱动返回[]byte, string时按类别解析，JSON类型的列反序列化，
+// 类别未知或解析失败时保留原始文本（[]byte转为string）
+func decodeColumn(value interface{}, kind columnKind, mode DecimalMode) interface{} {
+	var text string
+	switch v := value.(type) {
+	case []byte:
+		if kind == columnBytes {
+			return v
+		}
+		text = string(v)
+		if kind == columnBool && len(v) == 1 && v[0] <= 1 {
+			// mysql的BIT(1)
+			return v[0] == 1
+		}
+	case string:
+		if kind == columnBytes {
+			return []byte(v)
+		}
+		text = v
+	default:
+		return decodeValue(value, kind, mode)
+	}
+	v, err := decodeText(text, kind, mode)
+	if err != nil {
+		return text
+	}
+	return v
+}
+
+// decodeText 按列的类别解析文本，类别未知时返回原文本
+func decodeText(text string, kind columnKind, mode DecimalMode) (interface{}, error) {
+	switch kind {
+	case columnInt:
+		return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	case columnUint:
+		i, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		if err != nil {
+			// 超出int64范围的BIGINT UNSIGNED
+			return strconv.ParseUint(strings.TrimSpace(text), 10, 64)
+		}
+		return i, nil
+	case columnFloat:
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case columnDecimal:
+		return decodeDecimal(strings.TrimSpace(text), mode)
+	case columnBool:
+		return strconv.ParseBool(strings.TrimSpace(text))
+	case columnJSON:
+		// JSON对象解码为map[string]interface{}，数组解码为[]interface{}
+		var v interface{}
+		if err := unmarshalJSON(text, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return text, nil
+}
+
+// decodeValue 驱动返回的值不是[]byte和string时，仅处理定点数的解码方式，其他保持不变
+func decodeValue(value interface{}, kind columnKind, mode DecimalMode) interface{} {
+	if kind != columnDecimal || mode == DecimalAsFloat64 {
+		return value
+	}
+	var text string
+	switch v := value.(type) {
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return value
+	}
+	v, err := decodeDecimal(text, mode)
+	if err != nil {
+		return value
+	}
+	return v
+}
+
+// decodeDecimal 按mode解码定点数的文本
+func decodeDecimal(text string, mode DecimalMode) (interface{}, error) {
+	switch mode {
+	case DecimalAsString:
+		return text, nil
+	case DecimalAsBigRat:
+		r, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return nil, fmt.Errorf("无法将%q转换为*big.Rat", text)
+		}
+		return r, nil
+	}
+	return strconv.ParseFloat(text, 64)
+}
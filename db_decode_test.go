@@ -0,0 +1,99 @@
+package easydb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestColumnDecode(t *testing.T) {
+	result := &fakeResult{
+		columns: []string{"code", "qty", "big", "active", "flag", "price", "data", "note", "uid"},
+		types:   []string{"VARCHAR(20)", "INT", "BIGINT UNSIGNED", "BOOL", "BIT", "DECIMAL(10,2)", "BLOB", "", "UUID"},
+		rows: [][]driver.Value{
+			{[]byte("0012"), []byte("12"), []byte("18446744073709551615"), []byte("t"), []byte{1}, []byte("12.30"), []byte{0, 1}, []byte("true"), []byte("550e8400")},
+		},
+	}
+	d := newFakeDb(t, result)
+	data := make(map[string]interface{})
+	if err := d.GetOneData("SELECT ...", data); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"code":   "0012",
+		"qty":    int64(12),
+		"big":    uint64(18446744073709551615),
+		"active": true,
+		"flag":   true,
+		"price":  12.3,
+		"data":   []byte{0, 1},
+		"note":   "true",
+		"uid":    "550e8400",
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("GetOneData got: %#v", data)
+	}
+
+	d.SetDecimalMode(DecimalAsString)
+	d.SetDecoder("uuid", func(value interface{}, col *sql.ColumnType) (interface{}, error) {
+		return strings.ToUpper(string(value.([]byte))), nil
+	})
+	var list []map[string]interface{}
+	if err := d.GetMany("SELECT ...", &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0]["price"] != "12.30" || list[0]["uid"] != "550E8400" {
+		t.Errorf("GetMany got: %#v", list)
+	}
+
+	d.SetDecimalMode(DecimalAsBigRat)
+	if err := d.GetOneData("SELECT ...", data); err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := data["price"].(*big.Rat); !ok || r.Cmp(big.NewRat(123, 10)) != 0 {
+		t.Errorf("GetOneData DecimalAsBigRat got: %#v", data["price"])
+	}
+
+	d2 := newFakeDb(t, &fakeResult{columns: []string{"v"}, types: []string{"INT"}, rows: [][]driver.Value{{[]byte("x")}}})
+	if err := d2.GetOneData("SELECT ...", data); err != nil || data["v"] != "x" {
+		t.Errorf("GetOneData should keep x as string, got: %#v %v", data["v"], err)
+	}
+	d3 := newFakeDb(t, &fakeResult{columns: []string{"v"}, types: []string{"NUMERIC"}, rows: [][]driver.Value{{[]byte("1.50")}, {nil}}})
+	d3.SetDecimalMode(DecimalAsString)
+	var values []interface{}
+	if err := d3.GetMany("SELECT ...", &values); err != nil || !reflect.DeepEqual(values, []interface{}{"1.50", nil}) {
+		t.Errorf("GetMany got: %#v %v", values, err)
+	}
+}
+
+func TestColumnDecodeFallback(t *testing.T) {
+	d := newFakeDb(t, &fakeResult{
+		columns: []string{"amount", "small"},
+		types:   []string{"MONEY", "SMALLMONEY"},
+		rows:    [][]driver.Value{{[]byte("$1,234.56"), []byte("12.3400")}},
+	})
+	var list []map[string]interface{}
+	if err := d.GetMany("SELECT ...", &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0]["amount"] != "$1,234.56" || list[0]["small"] != "12.3400" {
+		t.Errorf("GetMany got: %#v", list)
+	}
+
+	d2 := newFakeDb(t, &fakeResult{
+		columns: []string{"mask", "flag", "bad"},
+		types:   []string{"BIT(4)", "BIT(1)", "BOOL"},
+		rows:    [][]driver.Value{{[]byte("0101"), []byte("1"), []byte("yes")}},
+	})
+	data := make(map[string]interface{})
+	if err := d2.GetOneData("SELECT ...", data); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"mask": "0101", "flag": true, "bad": "yes"}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("GetOneData got: %#v", data)
+	}
+}
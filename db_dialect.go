@@ -0,0 +1,406 @@
+package easydb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Dialect 数据库方言。屏蔽各数据库在占位符、分页、RETURNING、UPSERT等语法上的差异。
+// EasyDb初始化时，根据数据库驱动自动选择方言。也可调用EasyDb.SetDialect设置自定义方言。
+type Dialect interface {
+	// Name 数据库类型：postgres, mysql, sqlite3, sqlserver, oracle
+	Name() string
+	// Placeholder 第index个参数的占位符，index从0开始。如 postgres: $1, mysql: ?
+	Placeholder(index int) string
+	// Quote 为表名、列名等标识符加引号。含.的标识符分段处理，如 public.users => "public"."users"
+	// 用于拼接SQL语句。ExecInsert、Upsert和查询构建器等生成的语句不为标识符加引号，表名、列名按传入的原样写入
+	Quote(identifier string) string
+	// LimitOffset 生成分页子句。limit小于等于0表示不限制条数，offset小于等于0表示不跳过。均不设置时返回空字符串
+	LimitOffset(limit, offset int) string
+	// SupportsReturning 是否支持 INSERT/UPDATE/DELETE ... RETURNING 子句
+	SupportsReturning() bool
+	// BoolLiteral 布尔值在SQL中的字面量
+	BoolLiteral(b bool) string
+	// Upsert 生成插入单行数据的语句，数据冲突时更新updateColumns列。
+	// updateColumns为空时，数据冲突则不做任何操作。参数占位符依次对应columns的值
+	Upsert(table string, columns, conflictColumns, updateColumns []string) string
+	// Savepoint 创建保存点的语句
+	Savepoint(name string) string
+	// ReleaseSavepoint 释放保存点的语句。数据库不支持时返回空字符串
+	ReleaseSavepoint(name string) string
+	// RollbackToSavepoint 回滚到保存点的语句
+	RollbackToSavepoint(name string) string
+	// MaxParams 单条语句允许的最大参数数量。批量插入时据此拆分语句
+	MaxParams() int
+	// MaxInsertRows 单条INSERT语句允许插入的最大行数。0表示不限制
+	MaxInsertRows() int
+}
+
+var (
+	dialectLock sync.RWMutex
+	dialects    = map[string]Dialect{
+		"postgres":  PostgresDialect{},
+		"pgx":       PostgresDialect{},
+		"mysql":     MysqlDialect{},
+		"sqlite3":   SqliteDialect{},
+		"sqlite":    SqliteDialect{},
+		"sqlserver": SqlserverDialect{},
+		"mssql":     SqlserverDialect{},
+		"oracle":    OracleDialect{},
+		"godror":    OracleDialect{},
+	}
+)
+
+// GetDialect 根据数据库类型或驱动名称获取方言。未知的数据库类型，返回使用?占位符的通用方言。
+func GetDialect(driverName string) Dialect {
+	dialectLock.RLock()
+	defer dialectLock.RUnlock()
+	if dl, ok := dialects[strings.ToLower(driverName)]; ok {
+		return dl
+	}
+	return commonDialect{name: driverName}
+}
+
+// RegisterDialect 注册数据库方言。已存在的driverName将被覆盖。
+func RegisterDialect(driverName string, dl Dialect) {
+	dialectLock.Lock()
+	defer dialectLock.Unlock()
+	dialects[strings.ToLower(driverName)] = dl
+}
+
+// quoteIdentifier 为标识符分段加引号。已加引号的部分和*保持不变。
+func quoteIdentifier(identifier, left, right string) string {
+	parts := strings.Split(identifier, ".")
+	for i, p := range parts {
+		if p == "*" || strings.HasPrefix(p, left) {
+			continue
+		}
+		parts[i] = left + strings.ReplaceAll(p, right, right+right) + right
+	}
+	return strings.Join(parts, ".")
+}
+
+// commonDialect 通用方言，使用标准SQL语法
+type commonDialect struct {
+	name string
+}
+
+func (c commonDialect) Name() string {
+	return c.name
+}
+
+func (commonDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (commonDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, `"`, `"`)
+}
+
+func (commonDialect) LimitOffset(limit, offset int) string {
+	var parts []string
+	if limit > 0 {
+		parts = append(parts, fmt.Sprintf("LIMIT %d", limit))
+	}
+	if offset > 0 {
+		parts = append(parts, fmt.Sprintf("OFFSET %d", offset))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (commonDialect) SupportsReturning() bool {
+	return false
+}
+
+func (commonDialect) BoolLiteral(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (c commonDialect) Upsert(table string, columns, conflictColumns, updateColumns []string) string {
+	return upsertOnConflict(c, table, columns, conflictColumns, updateColumns)
+}
+
+func (commonDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (commonDialect) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func (commonDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+// MaxParams PostgreSQL, MySQL, Oracle均为65535
+func (commonDialect) MaxParams() int {
+	return 65535
+}
+
+func (commonDialect) MaxInsertRows() int {
+	return 0
+}
+
+// PostgresDialect PostgreSQL方言
+type PostgresDialect struct {
+	commonDialect
+}
+
+func (PostgresDialect) Name() string {
+	return "postgres"
+}
+
+// Placeholder $1, $2, $3 ...
+func (PostgresDialect) Placeholder(index int) string {
+	return fmt.Sprintf("$%d", index+1)
+}
+
+func (PostgresDialect) SupportsReturning() bool {
+	return true
+}
+
+// Upsert INSERT ... ON CONFLICT (...) DO UPDATE SET ...
+func (p PostgresDialect) Upsert(table string, columns, conflictColumns, updateColumns []string) string {
+	return upsertOnConflict(p, table, columns, conflictColumns, updateColumns)
+}
+
+// SqliteDialect SQLite方言。RETURNING子句需要SQLite 3.35及以上版本，UPSERT需要3.24及以上版本。
+type SqliteDialect struct {
+	commonDialect
+}
+
+func (SqliteDialect) Name() string {
+	return "sqlite3"
+}
+
+// LimitOffset SQLite的OFFSET必须跟在LIMIT之后，LIMIT -1表示不限制条数
+func (SqliteDialect) LimitOffset(limit, offset int) string {
+	if limit <= 0 && offset > 0 {
+		return fmt.Sprintf("LIMIT -1 OFFSET %d", offset)
+	}
+	return commonDialect{}.LimitOffset(limit, offset)
+}
+
+func (SqliteDialect) SupportsReturning() bool {
+	return true
+}
+
+func (SqliteDialect) BoolLiteral(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// MaxParams SQLite 3.32以下版本为999，3.32及以上版本为32766。默认使用999，可调用EasyDb.SetMaxParams调整
+func (SqliteDialect) MaxParams() int {
+	return 999
+}
+
+// Upsert INSERT ... ON CONFLICT (...) DO UPDATE SET ...
+func (s SqliteDialect) Upsert(table string, columns, conflictColumns, updateColumns []string) string {
+	return upsertOnConflict(s, table, columns, conflictColumns, updateColumns)
+}
+
+// MysqlDialect MySQL方言
+type MysqlDialect struct {
+	commonDialect
+}
+
+func (MysqlDialect) Name() string {
+	return "mysql"
+}
+
+// Quote `identifier`
+func (MysqlDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, "`", "`")
+}
+
+// LimitOffset MySQL的OFFSET必须跟在LIMIT之后，使用最大值表示不限制条数
+func (MysqlDialect) LimitOffset(limit, offset int) string {
+	if limit <= 0 && offset > 0 {
+		return fmt.Sprintf("LIMIT 18446744073709551615 OFFSET %d", offset)
+	}
+	return commonDialect{}.LimitOffset(limit, offset)
+}
+
+// Upsert INSERT ... ON DUPLICATE KEY UPDATE ...
+// MySQL根据表的主键和唯一索引判断冲突，conflictColumns不会出现在语句中。
+// updateColumns为空时，使用INSERT IGNORE。
+func (m MysqlDialect) Upsert(table string, columns, conflictColumns, updateColumns []string) string {
+	values := placeholders(m, 0, len(columns))
+	if len(updateColumns) == 0 {
+		return fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), values)
+	}
+	sets := make([]string, len(updateColumns))
+	for i, col := range updateColumns {
+		sets[i] = fmt.Sprintf("%s = VALUES(%s)", col, col)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s", table, strings.Join(columns, ", "), values, strings.Join(sets, ", "))
+}
+
+// SqlserverDialect SQL Server方言
+type SqlserverDialect struct {
+	commonDialect
+}
+
+func (SqlserverDialect) Name() string {
+	return "sqlserver"
+}
+
+// Placeholder @p1, @p2, @p3 ...
+func (SqlserverDialect) Placeholder(index int) string {
+	return fmt.Sprintf("@p%d", index+1)
+}
+
+// Quote [identifier]
+func (SqlserverDialect) Quote(identifier string) string {
+	return quoteIdentifier(identifier, "[", "]")
+}
+
+// LimitOffset OFFSET ... ROWS FETCH NEXT ... ROWS ONLY。SQL Server要求语句中必须有ORDER BY子句
+func (SqlserverDialect) LimitOffset(limit, offset int) string {
+	return offsetFetch(limit, offset)
+}
+
+func (SqlserverDialect) BoolLiteral(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// Upsert MERGE INTO ... USING (VALUES (...)) ...
+func (s SqlserverDialect) Upsert(table string, columns, conflictColumns, updateColumns []string) string {
+	source := fmt.Sprintf("(VALUES (%s)) AS source (%s)", placeholders(s, 0, len(columns)), strings.Join(columns, ", "))
+	return upsertMerge(table+" WITH (HOLDLOCK) AS target", source, columns, conflictColumns, updateColumns) + ";"
+}
+
+// MaxParams SQL Server单个请求最多2100个参数。驱动通过sp_executesql执行，语句和参数定义占用2个参数
+func (SqlserverDialect) MaxParams() int {
+	return 2098
+}
+
+// MaxInsertRows SQL Server的VALUES子句最多1000行
+func (SqlserverDialect) MaxInsertRows() int {
+	return 1000
+}
+
+func (SqlserverDialect) Savepoint(name string) string {
+	return "SAVE TRANSACTION " + name
+}
+
+// ReleaseSavepoint SQL Server不支持释放保存点
+func (SqlserverDialect) ReleaseSavepoint(name string) string {
+	return ""
+}
+
+func (SqlserverDialect) RollbackToSavepoint(name string) string {
+	return "ROLLBACK TRANSACTION " + name
+}
+
+// OracleDialect Oracle方言。分页语法需要Oracle 12c及以上版本。
+type OracleDialect struct {
+	commonDialect
+}
+
+func (OracleDialect) Name() string {
+	return "oracle"
+}
+
+// Placeholder :1, :2, :3 ...
+func (OracleDialect) Placeholder(index int) string {
+	return fmt.Sprintf(":%d", index+1)
+}
+
+// LimitOffset OFFSET ... ROWS FETCH NEXT ... ROWS ONLY
+func (OracleDialect) LimitOffset(limit, offset int) string {
+	return offsetFetch(limit, offset)
+}
+
+func (OracleDialect) BoolLiteral(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// Upsert MERGE INTO ... USING (SELECT ... FROM DUAL) ...
+func (o OracleDialect) Upsert(table string, columns, conflictColumns, updateColumns []string) string {
+	selects := make([]string, len(columns))
+	for i, col := range columns {
+		selects[i] = fmt.Sprintf("%s AS %s", o.Placeholder(i), col)
+	}
+	source := fmt.Sprintf("(SELECT %s FROM DUAL) source", strings.Join(selects, ", "))
+	return upsertMerge(table+" target", source, columns, conflictColumns, updateColumns)
+}
+
+// ReleaseSavepoint Oracle不支持释放保存点
+func (OracleDialect) ReleaseSavepoint(name string) string {
+	return ""
+}
+
+// placeholders 生成从start开始的n个以逗号分隔的参数占位符
+func placeholders(dl Dialect, start, n int) string {
+	list := make([]string, n)
+	for i := range list {
+		list[i] = dl.Placeholder(start + i)
+	}
+	return strings.Join(list, ", ")
+}
+
+// offsetFetch SQL:2008标准的分页语法
+func offsetFetch(limit, offset int) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	clause := fmt.Sprintf("OFFSET %d ROWS", offset)
+	if limit > 0 {
+		clause += fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", limit)
+	}
+	return clause
+}
+
+// upsertOnConflict INSERT ... ON CONFLICT (...) DO UPDATE SET ... / DO NOTHING
+func upsertOnConflict(dl Dialect, table string, columns, conflictColumns, updateColumns []string) string {
+	sqlText := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT", table, strings.Join(columns, ", "), placeholders(dl, 0, len(columns)))
+	if len(conflictColumns) > 0 {
+		sqlText += fmt.Sprintf(" (%s)", strings.Join(conflictColumns, ", "))
+	}
+	if len(updateColumns) == 0 {
+		return sqlText + " DO NOTHING"
+	}
+	sets := make([]string, len(updateColumns))
+	for i, col := range updateColumns {
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", col, col)
+	}
+	return sqlText + " DO UPDATE SET " + strings.Join(sets, ", ")
+}
+
+// upsertMerge MERGE INTO target USING source ON (...) WHEN MATCHED ... WHEN NOT MATCHED ...
+func upsertMerge(target, source string, columns, conflictColumns, updateColumns []string) string {
+	ons := make([]string, len(conflictColumns))
+	for i, col := range conflictColumns {
+		ons[i] = fmt.Sprintf("target.%s = source.%s", col, col)
+	}
+	sqlText := fmt.Sprintf("MERGE INTO %s USING %s ON (%s)", target, source, strings.Join(ons, " AND "))
+	if len(updateColumns) > 0 {
+		sets := make([]string, len(updateColumns))
+		for i, col := range updateColumns {
+			sets[i] = fmt.Sprintf("%s = source.%s", col, col)
+		}
+		sqlText += " WHEN MATCHED THEN UPDATE SET " + strings.Join(sets, ", ")
+	}
+	values := make([]string, len(columns))
+	for i, col := range columns {
+		values[i] = "source." + col
+	}
+	return sqlText + fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", strings.Join(columns, ", "), strings.Join(values, ", "))
+}
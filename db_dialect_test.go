@@ -0,0 +1,79 @@
+package easydb
+
+import (
+	"testing"
+)
+
+func TestDialectPlaceholder(t *testing.T) {
+	cases := map[string]string{
+		"postgres":  "$2",
+		"pgx":       "$2",
+		"mysql":     "?",
+		"sqlite3":   "?",
+		"sqlite":    "?",
+		"sqlserver": "@p2",
+		"oracle":    ":2",
+		"unknown":   "?",
+	}
+	for driverName, want := range cases {
+		if got := GetPlaceholder(driverName, 1); got != want {
+			t.Errorf("GetPlaceholder(%s) = %s, want %s", driverName, got, want)
+		}
+	}
+}
+
+func TestDialectLimitOffset(t *testing.T) {
+	cases := []struct {
+		driverName    string
+		limit, offset int
+		want          string
+	}{
+		{"postgres", 10, 20, "LIMIT 10 OFFSET 20"},
+		{"postgres", 0, 20, "OFFSET 20"},
+		{"mysql", 0, 20, "LIMIT 18446744073709551615 OFFSET 20"},
+		{"sqlite3", 0, 20, "LIMIT -1 OFFSET 20"},
+		{"sqlserver", 10, 0, "OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{"oracle", 0, 0, ""},
+	}
+	for _, c := range cases {
+		if got := GetDialect(c.driverName).LimitOffset(c.limit, c.offset); got != c.want {
+			t.Errorf("%s LimitOffset(%d, %d) = %s, want %s", c.driverName, c.limit, c.offset, got, c.want)
+		}
+	}
+}
+
+func TestDialectQuote(t *testing.T) {
+	cases := map[string]string{
+		"postgres":  `"public"."users"`,
+		"mysql":     "`public`.`users`",
+		"sqlserver": "[public].[users]",
+	}
+	for driverName, want := range cases {
+		if got := GetDialect(driverName).Quote("public.users"); got != want {
+			t.Errorf("%s Quote = %s, want %s", driverName, got, want)
+		}
+	}
+}
+
+func TestDialectUpsert(t *testing.T) {
+	cols := []string{"id", "name", "age"}
+	conflict := []string{"id"}
+	update := []string{"name", "age"}
+	cases := []struct {
+		driverName string
+		update     []string
+		want       string
+	}{
+		{"postgres", update, "INSERT INTO users (id, name, age) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, age = EXCLUDED.age"},
+		{"sqlite3", nil, "INSERT INTO users (id, name, age) VALUES (?, ?, ?) ON CONFLICT (id) DO NOTHING"},
+		{"mysql", update, "INSERT INTO users (id, name, age) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE name = VALUES(name), age = VALUES(age)"},
+		{"mysql", nil, "INSERT IGNORE INTO users (id, name, age) VALUES (?, ?, ?)"},
+		{"sqlserver", update, "MERGE INTO users WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2, @p3)) AS source (id, name, age) ON (target.id = source.id) WHEN MATCHED THEN UPDATE SET name = source.name, age = source.age WHEN NOT MATCHED THEN INSERT (id, name, age) VALUES (source.id, source.name, source.age);"},
+		{"oracle", nil, "MERGE INTO users target USING (SELECT :1 AS id, :2 AS name, :3 AS age FROM DUAL) source ON (target.id = source.id) WHEN NOT MATCHED THEN INSERT (id, name, age) VALUES (source.id, source.name, source.age)"},
+	}
+	for _, c := range cases {
+		if got := GetDialect(c.driverName).Upsert("users", cols, conflict, c.update); got != c.want {
+			t.Errorf("%s Upsert:\n got: %s\nwant: %s", c.driverName, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package easydb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrorKind 数据库错误的分类
+type ErrorKind int
+
+const (
+	// ErrorKindUnknown 未分类的错误
+	ErrorKindUnknown ErrorKind = iota
+	// ErrorKindUniqueViolation 违反唯一约束或主键约束
+	ErrorKindUniqueViolation
+	// ErrorKindForeignKeyViolation 违反外键约束
+	ErrorKindForeignKeyViolation
+	// ErrorKindNotNullViolation 违反非空约束
+	ErrorKindNotNullViolation
+	// ErrorKindDeadlock 死锁
+	ErrorKindDeadlock
+	// ErrorKindSerialization 可串行化隔离级别下的事务冲突
+	ErrorKindSerialization
+	// ErrorKindConnection 连接失败或连接中断
+	ErrorKindConnection
+	// ErrorKindTimeout 语句超时或等待锁超时
+	ErrorKindTimeout
+)
+
+// DbError 与数据库驱动无关的数据库错误信息。使用AsDbError从错误链中获取。
+type DbError struct {
+	// Driver 数据库类型：postgres, mysql, sqlite3, sqlserver, oracle
+	Driver string
+	// Code 错误码。postgres为SQLSTATE，sqlite3为扩展错误码，其他数据库为错误编号
+	Code string
+	// Kind 错误分类
+	Kind ErrorKind
+	// Message 数据库返回的错误信息
+	Message string
+	// Constraint 违反的约束名称。postgres由驱动提供，其他数据库从错误信息中解析，无法获取时为空
+	Constraint string
+	// Table 表名。无法获取时为空
+	Table string
+	// Column 列名。无法获取时为空
+	Column string
+	// Err 数据库驱动返回的原始错误
+	Err error
+}
+
+func (e *DbError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DbError) Unwrap() error {
+	return e.Err
+}
+
+// AsDbError 在错误链中查找数据库驱动返回的错误，转换为*DbError。
+// 支持 lib/pq, pgx, go-sql-driver/mysql, go-sqlite3, modernc.org/sqlite, go-mssqldb, godror, go-ora。
+// 示例：
+//
+//	_, err := d.Exec("INSERT INTO users (name) VALUES (?)", "Tom")
+//	if dbErr, ok := easydb.AsDbError(err); ok && dbErr.Kind == easydb.ErrorKindUniqueViolation {
+//		fmt.Println(dbErr.Constraint, dbErr.Table, dbErr.Column)
+//	}
+func AsDbError(err error) (*DbError, bool) {
+	var dbErr *DbError
+	if errors.As(err, &dbErr) {
+		return dbErr, true
+	}
+	var found *DbError
+	walkError(err, func(e error) bool {
+		found = parseDriverError(e)
+		return found != nil
+	})
+	return found, found != nil
+}
+
+// walkError 遍历错误链，包括errors.Join合并的错误。fn返回true时停止遍历。
+func walkError(err error, fn func(e error) bool) bool {
+	for err != nil {
+		if fn(err) {
+			return true
+		}
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range x.Unwrap() {
+				if walkError(e, fn) {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// parseDriverError 将数据库驱动返回的错误转换为*DbError，不是数据库驱动的错误时返回nil。
+// 为避免依赖各数据库驱动，通过反射读取错误结构体的字段或方法：
+// lib/pq的Error.Code, pgx的PgError.SQLState(), mysql的MySQLError.Number, go-sqlite3的Error.ExtendedCode,
+// modernc.org/sqlite的Error.Code(), mssql的Error.Number, godror的OraErr.Code(), go-ora的OracleError.ErrCode
+func parseDriverError(err error) *DbError {
+	t := reflect.TypeOf(err)
+	v := reflect.ValueOf(err)
+	for t.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		t = t.Elem()
+		v = v.Elem()
+	}
+	driverName := getDriverNameByPkg(t.PkgPath())
+	if driverName == "" {
+		return nil
+	}
+	de := &DbError{Driver: driverName, Err: err, Message: err.Error()}
+	// 优先使用Code() int或SQLState() string方法
+	switch x := err.(type) {
+	case interface{ SQLState() string }:
+		de.Code = x.SQLState()
+	case interface{ Code() int }:
+		de.Code = strconv.Itoa(x.Code())
+	}
+	if x, ok := err.(interface{ Message() string }); ok {
+		de.Message = x.Message()
+	}
+	if t.Kind() == reflect.Struct {
+		if de.Code == "" {
+			de.Code = fieldString(v, "ExtendedCode", "Code", "Number", "ErrCode")
+		}
+		if msg := fieldString(v, "Message", "Msg", "ErrMsg"); msg != "" {
+			de.Message = msg
+		}
+		de.Constraint = fieldString(v, "Constraint", "ConstraintName")
+		de.Table = fieldString(v, "Table", "TableName")
+		de.Column = fieldString(v, "Column", "ColumnName")
+	}
+	de.Kind = errorKind(de.Driver, de.Code, de.Message)
+	parseErrorMessage(de)
+	return de
+}
+
+// fieldString 读取结构体中第一个存在的字符串或整数字段，转换为字符串
+func fieldString(v reflect.Value, names ...string) string {
+	for _, name := range names {
+		f := v.FieldByName(name)
+		if !f.IsValid() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.String:
+			return f.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(f.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.FormatUint(f.Uint(), 10)
+		}
+	}
+	return ""
+}
+
+// errorCodes 各数据库的错误码与错误分类的对应关系
+var errorCodes = map[string]map[string]ErrorKind{
+	"postgres": {
+		"23505": ErrorKindUniqueViolation,
+		"23503": ErrorKindForeignKeyViolation,
+		"23502": ErrorKindNotNullViolation,
+		"40P01": ErrorKindDeadlock,
+		"40001": ErrorKindSerialization,
+		"57014": ErrorKindTimeout, // query_canceled，包括statement_timeout
+		"55P03": ErrorKindTimeout, // lock_not_available，包括lock_timeout
+		"57P01": ErrorKindConnection,
+		"57P02": ErrorKindConnection,
+		"57P03": ErrorKindConnection,
+	},
+	"mysql": {
+		"1062": ErrorKindUniqueViolation, // ER_DUP_ENTRY
+		"1586": ErrorKindUniqueViolation, // ER_DUP_ENTRY_WITH_KEY_NAME
+		"1216": ErrorKindForeignKeyViolation,
+		"1217": ErrorKindForeignKeyViolation,
+		"1451": ErrorKindForeignKeyViolation,
+		"1452": ErrorKindForeignKeyViolation,
+		"1048": ErrorKindNotNullViolation, // ER_BAD_NULL_ERROR
+		"1364": ErrorKindNotNullViolation, // ER_NO_DEFAULT_FOR_FIELD
+		"1213": ErrorKindDeadlock,
+		"1205": ErrorKindTimeout, // ER_LOCK_WAIT_TIMEOUT
+		"3024": ErrorKindTimeout, // ER_QUERY_TIMEOUT
+		"1040": ErrorKindConnection,
+		"1053": ErrorKindConnection,
+		"2002": ErrorKindConnection,
+		"2003": ErrorKindConnection,
+		"2006": ErrorKindConnection,
+		"2013": ErrorKindConnection,
+	},
+	"sqlite3": {
+		"2067": ErrorKindUniqueViolation, // SQLITE_CONSTRAINT_UNIQUE
+		"1555": ErrorKindUniqueViolation, // SQLITE_CONSTRAINT_PRIMARYKEY
+		"787":  ErrorKindForeignKeyViolation,
+		"1299": ErrorKindNotNullViolation,
+		"5":    ErrorKindTimeout, // SQLITE_BUSY，等待锁超时
+	},
+	"sqlserver": {
+		"2627": ErrorKindUniqueViolation,
+		"2601": ErrorKindUniqueViolation,
+		"515":  ErrorKindNotNullViolation,
+		"1205": ErrorKindDeadlock,
+		"1222": ErrorKindTimeout, // Lock request time out period exceeded
+	},
+	"oracle": {
+		"1":     ErrorKindUniqueViolation, // ORA-00001
+		"2291":  ErrorKindForeignKeyViolation,
+		"2292":  ErrorKindForeignKeyViolation,
+		"1400":  ErrorKindNotNullViolation,
+		"60":    ErrorKindDeadlock,
+		"8177":  ErrorKindSerialization,
+		"1013":  ErrorKindTimeout, // 用户取消操作，context超时时返回
+		"30006": ErrorKindTimeout,
+		"3113":  ErrorKindConnection,
+		"3114":  ErrorKindConnection,
+		"3135":  ErrorKindConnection,
+		"12170": ErrorKindConnection,
+		"12541": ErrorKindConnection,
+	},
+}
+
+// errorKind 根据数据库类型和错误码获取错误分类
+func errorKind(driverName, code, message string) ErrorKind {
+	if kind, ok := errorCodes[driverName][code]; ok {
+		return kind
+	}
+	switch driverName {
+	case "postgres":
+		// 08类：连接异常
+		if strings.HasPrefix(code, "08") {
+			return ErrorKindConnection
+		}
+	case "sqlserver":
+		// 547为约束冲突，包括外键约束和CHECK约束
+		if code == "547" && strings.Contains(message, "FOREIGN KEY") {
+			return ErrorKindForeignKeyViolation
+		}
+	}
+	return ErrorKindUnknown
+}
+
+var (
+	// mysql: Duplicate entry 'Tom' for key 'users.name'
+	mysqlDuplicateRe = regexp.MustCompile("for key '([^']+)'")
+	// mysql: ... CONSTRAINT `fk_name` FOREIGN KEY (`user_id`) REFERENCES ...
+	mysqlForeignKeyRe = regexp.MustCompile("CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`\\)")
+	// mysql: Column 'name' cannot be null
+	mysqlColumnRe = regexp.MustCompile("(?:Column|Field) '([^']+)'")
+	// sqlite3: UNIQUE constraint failed: users.name, users.age
+	sqliteConstraintRe = regexp.MustCompile(`constraint failed: (\w+)\.(\w+)`)
+	// sqlserver: Violation of UNIQUE KEY constraint 'UQ_users_name'. ... in object 'dbo.users'
+	// sqlserver: The INSERT statement conflicted with the FOREIGN KEY constraint "FK_orders_users". ... table "dbo.users", column 'id'
+	mssqlConstraintRe = regexp.MustCompile(`constraint ['"]([^'"]+)['"]`)
+	mssqlTableRe      = regexp.MustCompile(`(?:object|table) ['"]([^'"]+)['"]`)
+	mssqlColumnRe     = regexp.MustCompile(`column '([^']+)'`)
+	// oracle: ORA-00001: unique constraint (SCOTT.UQ_USERS_NAME) violated
+	// oracle: ORA-01400: cannot insert NULL into ("SCOTT"."USERS"."NAME")
+	oracleConstraintRe = regexp.MustCompile(`constraint \(([^)]+)\)`)
+	oracleColumnRe     = regexp.MustCompile(`\("[^"]+"\."([^"]+)"\."([^"]+)"\)`)
+)
+
+// parseErrorMessage 驱动没有提供约束名称、表名、列名时，从错误信息中解析
+func parseErrorMessage(de *DbError) {
+	if de.Constraint != "" || de.Table != "" || de.Column != "" {
+		return
+	}
+	msg := de.Message
+	switch de.Driver {
+	case "mysql":
+		if m := mysqlDuplicateRe.FindStringSubmatch(msg); m != nil {
+			de.Constraint = m[1]
+			if table, key, ok := strings.Cut(m[1], "."); ok {
+				de.Table, de.Constraint = table, key
+			}
+		} else if m := mysqlForeignKeyRe.FindStringSubmatch(msg); m != nil {
+			de.Constraint, de.Column = m[1], m[2]
+		} else if m := mysqlColumnRe.FindStringSubmatch(msg); m != nil {
+			de.Column = m[1]
+		}
+	case "sqlite3":
+		if m := sqliteConstraintRe.FindStringSubmatch(msg); m != nil {
+			de.Table, de.Column = m[1], m[2]
+		}
+	case "sqlserver":
+		if m := mssqlConstraintRe.FindStringSubmatch(msg); m != nil {
+			de.Constraint = m[1]
+		}
+		if m := mssqlTableRe.FindStringSubmatch(msg); m != nil {
+			de.Table = m[1]
+		}
+		if m := mssqlColumnRe.FindStringSubmatch(msg); m != nil {
+			de.Column = m[1]
+		}
+	case "oracle":
+		if m := oracleConstraintRe.FindStringSubmatch(msg); m != nil {
+			de.Constraint = m[1]
+		} else if m := oracleColumnRe.FindStringSubmatch(msg); m != nil {
+			de.Table, de.Column = m[1], m[2]
+		}
+	}
+}
+
+// isKind 判断错误链中的数据库错误是否为kind类型
+func isKind(err error, kind ErrorKind) bool {
+	if err == nil {
+		return false
+	}
+	de, ok := AsDbError(err)
+	return ok && de.Kind == kind
+}
+
+// IsUniqueViolation 判断错误是否为违反唯一约束或主键约束
+func IsUniqueViolation(err error) bool {
+	return isKind(err, ErrorKindUniqueViolation)
+}
+
+// IsForeignKeyViolation 判断错误是否为违反外键约束
+func IsForeignKeyViolation(err error) bool {
+	return isKind(err, ErrorKindForeignKeyViolation)
+}
+
+// IsNotNullViolation 判断错误是否为违反非空约束
+func IsNotNullViolation(err error) bool {
+	return isKind(err, ErrorKindNotNullViolation)
+}
+
+// IsDeadlock 判断错误是否为死锁
+func IsDeadlock(err error) bool {
+	return isKind(err, ErrorKindDeadlock)
+}
+
+// IsConnectionError 判断错误是否为连接失败或连接中断。
+// 包括driver.ErrBadConn, sql.ErrConnDone, 网络错误，以及数据库返回的连接类错误码
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr *net.OpError
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return isKind(err, ErrorKindConnection)
+}
+
+// IsTimeout 判断错误是否为超时。
+// 包括context.DeadlineExceeded, 网络超时，以及数据库返回的语句超时、等待锁超时的错误码
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return isKind(err, ErrorKindTimeout)
+}
+
+// IsRetryable 判断错误是否为可重试的事务冲突，即序列化失败或死锁。
+// postgres: 40001(serialization_failure), 40P01(deadlock_detected)
+// mysql: 1213(ER_LOCK_DEADLOCK)
+// sqlserver: 1205, oracle: ORA-00060, ORA-08177
+func IsRetryable(err error) bool {
+	return isKind(err, ErrorKindDeadlock) || isKind(err, ErrorKindSerialization)
+}
@@ -0,0 +1,73 @@
+package easydb
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		driver  string
+		code    string
+		message string
+		want    ErrorKind
+	}{
+		{"postgres", "23505", "", ErrorKindUniqueViolation},
+		{"postgres", "08006", "", ErrorKindConnection},
+		{"mysql", "1452", "", ErrorKindForeignKeyViolation},
+		{"sqlite3", "1299", "", ErrorKindNotNullViolation},
+		{"sqlserver", "547", `The INSERT statement conflicted with the FOREIGN KEY constraint "FK_orders_users".`, ErrorKindForeignKeyViolation},
+		{"sqlserver", "547", `The INSERT statement conflicted with the CHECK constraint "CK_age".`, ErrorKindUnknown},
+		{"oracle", "60", "", ErrorKindDeadlock},
+	}
+	for _, tt := range tests {
+		if got := errorKind(tt.driver, tt.code, tt.message); got != tt.want {
+			t.Errorf("errorKind(%s, %s) got: %v, want: %v", tt.driver, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	tests := []struct {
+		driver     string
+		message    string
+		constraint string
+		table      string
+		column     string
+	}{
+		{"mysql", "Duplicate entry 'Tom' for key 'users.uq_name'", "uq_name", "users", ""},
+		{"mysql", "Cannot add or update a child row: a foreign key constraint fails (`test`.`orders`, CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))", "fk_user", "", "user_id"},
+		{"mysql", "Column 'name' cannot be null", "", "", "name"},
+		{"sqlite3", "UNIQUE constraint failed: users.name", "", "users", "name"},
+		{"sqlserver", "Violation of UNIQUE KEY constraint 'UQ_users_name'. Cannot insert duplicate key in object 'dbo.users'.", "UQ_users_name", "dbo.users", ""},
+		{"sqlserver", "Cannot insert the value NULL into column 'name', table 'test.dbo.users'; column does not allow nulls.", "", "test.dbo.users", "name"},
+		{"oracle", "ORA-00001: unique constraint (SCOTT.UQ_USERS_NAME) violated", "SCOTT.UQ_USERS_NAME", "", ""},
+		{"oracle", `ORA-01400: cannot insert NULL into ("SCOTT"."USERS"."NAME")`, "", "USERS", "NAME"},
+	}
+	for _, tt := range tests {
+		de := &DbError{Driver: tt.driver, Message: tt.message}
+		parseErrorMessage(de)
+		if de.Constraint != tt.constraint || de.Table != tt.table || de.Column != tt.column {
+			t.Errorf("parseErrorMessage(%s) got: %q %q %q", tt.message, de.Constraint, de.Table, de.Column)
+		}
+	}
+}
+
+func TestErrorClassify(t *testing.T) {
+	dbErr := &DbError{Driver: "postgres", Code: "23505", Kind: ErrorKindUniqueViolation, Err: fmt.Errorf("duplicate key")}
+	err := fmt.Errorf("插入数据失败: %w", dbErr)
+	if !IsUniqueViolation(err) || IsDeadlock(err) || IsRetryable(err) {
+		t.Error("classify wrapped *DbError failed")
+	}
+	if !IsTimeout(fmt.Errorf("查询失败: %w", context.DeadlineExceeded)) {
+		t.Error("IsTimeout(context.DeadlineExceeded) should be true")
+	}
+	if !IsConnectionError(fmt.Errorf("查询失败: %w", driver.ErrBadConn)) {
+		t.Error("IsConnectionError(driver.ErrBadConn) should be true")
+	}
+	if IsUniqueViolation(nil) || IsConnectionError(nil) || IsTimeout(nil) {
+		t.Error("nil error should not be classified")
+	}
+}
@@ -1,6 +1,7 @@
 package easydb
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -10,11 +11,41 @@ import (
 
 // Exec 重写Exec方法以记录SQL查询
 func (d *EasyDb) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.ExecContext(ctx, query, args...)
+}
+
+// ExecContext 同Exec，使用ctx控制执行的取消和超时
+func (d *EasyDb) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return d.exec(ctx, d.db, query, args...)
+}
+
+// exec Exec的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) exec(ctx context.Context, conn sqlConn, query string, args ...interface{}) (sql.Result, error) {
+	query, args, err := d.bindQuery(query, args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return d.execBound(ctx, conn, query, args...)
+}
+
+// execBound 执行已处理好占位符的SQL语句
+func (d *EasyDb) execBound(ctx context.Context, conn sqlConn, query string, args ...interface{}) (sql.Result, error) {
+	if d.guard != (WriteGuard{}) {
+		return d.execGuarded(ctx, conn, query, args...)
+	}
+	return d.execLogged(ctx, conn, query, args...)
+}
+
+// execLogged 执行SQL语句并记录日志
+func (d *EasyDb) execLogged(ctx context.Context, conn sqlConn, query string, args ...interface{}) (sql.Result, error) {
+	args = d.driverArgs(args)
 	start := time.Now()
 	if d.loglevel > 1 {
 		log.Printf("Exec SQL: (%s) args: (%v)", query, args)
 	}
-	result, err := d.db.Exec(query, args...)
+	result, err := conn.ExecContext(ctx, query, args...)
 	if d.loglevel > 0 {
 		// 中文字符在SSH控制台可能输出UTF-8 编码的字节序列，每个字节表示成十六进制的 <XX> 形式
 		log.Printf("SQL Exec Done. Cost: %v", time.Since(start))
@@ -24,43 +55,38 @@ func (d *EasyDb) Exec(query string, args ...interface{}) (sql.Result, error) {
 
 // ExecByFile 从文件执行SQL脚本
 func (d *EasyDb) ExecByFile(filepath string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.ExecByFileContext(ctx, filepath, args...)
+}
+
+// ExecByFileContext 同ExecByFile，使用ctx控制执行的取消和超时
+func (d *EasyDb) ExecByFileContext(ctx context.Context, filepath string, args ...interface{}) (sql.Result, error) {
 	// 读取SQL文件内容
 	sqlBytes, err := os.ReadFile(filepath)
 	if err != nil {
 		return nil, err
 	}
-	return d.Exec(string(sqlBytes), args...)
+	return d.ExecContext(ctx, string(sqlBytes), args...)
 }
 
 // ExecSqlWithTransaction 在事务中执行多条SQL语句
 func (d *EasyDb) ExecSqlWithTransaction(sqlStatements []string) error {
-	// 开始事务
-	tx, err := d.db.Begin()
-	if err != nil {
-		return fmt.Errorf("开始事务失败: %v", err)
-	}
-
-	// 确保函数结束时要么提交要么回滚事务
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p) // 重新抛出panic
-		}
-	}()
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.ExecSqlWithTransactionContext(ctx, sqlStatements)
+}
 
-	// 执行每条SQL语句
-	for _, sql := range sqlStatements {
-		_, err := tx.Exec(sql)
-		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("执行SQL失败: %v", err)
+// ExecSqlWithTransactionContext 同ExecSqlWithTransaction，使用ctx控制事务的取消和超时
+func (d *EasyDb) ExecSqlWithTransactionContext(ctx context.Context, sqlStatements []string) error {
+	return d.WithTx(ctx, nil, func(tx *EasyTx) error {
+		// 执行每条SQL语句
+		for _, sql := range sqlStatements {
+			_, err := tx.ExecContext(ctx, sql)
+			if err != nil {
+				return fmt.Errorf("执行SQL失败: %w", err)
+			}
 		}
-	}
-
-	// 提交事务
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("提交事务失败: %v", err)
-	}
-
-	return nil
+		return nil
+	})
 }
@@ -0,0 +1,200 @@
+package easydb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDriver 测试用的数据库驱动。查询时忽略SQL语句，返回预设的结果集；执行时记录SQL语句和参数。
+type fakeDriver struct{}
+
+// fakeResult 预设的结果集
+type fakeResult struct {
+	columns []string
+	// types 各列的数据库类型名称，对应ColumnType.DatabaseTypeName()，可为空
+	types []string
+	rows  [][]driver.Value
+
+	// rowsAffected 执行SQL语句时返回的受影响的行数，为nil时返回1
+	rowsAffected func(query string) int64
+	// err 执行或查询时返回的错误，为nil时不返回错误
+	err func(query string) error
+	// delay 查询前等待的时间，等待期间ctx被取消时返回ctx.Err()
+	delay time.Duration
+
+	mu       sync.Mutex
+	lastExec string
+	lastArgs []driver.Value
+	// log 依次执行的SQL语句和查询，事务操作记录为BEGIN, COMMIT, ROLLBACK
+	log []string
+}
+
+// record 记录执行的SQL语句，返回预设的错误
+func (r *fakeResult) record(query string) error {
+	r.mu.Lock()
+	r.log = append(r.log, query)
+	r.mu.Unlock()
+	if r.err != nil {
+		return r.err(query)
+	}
+	return nil
+}
+
+// executed 获取已执行的SQL语句并清空记录
+func (r *fakeResult) executed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	log := r.log
+	r.log = nil
+	return log
+}
+
+var (
+	fakeResults sync.Map
+	fakeSeq     atomic.Int64
+)
+
+func init() {
+	sql.Register("easydb_fake", fakeDriver{})
+}
+
+// newFakeDb 创建使用测试驱动的EasyDb，所有查询均返回result
+func newFakeDb(tb testing.TB, result *fakeResult) *EasyDb {
+	dsn := fmt.Sprintf("fake%d", fakeSeq.Add(1))
+	fakeResults.Store(dsn, result)
+	sqldb, err := sql.Open("easydb_fake", dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		sqldb.Close()
+		fakeResults.Delete(dsn)
+	})
+	return NewEasyDbBySqlDB(sqldb)
+}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	r, ok := fakeResults.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("unknown dsn %s", dsn)
+	}
+	return &fakeConn{result: r.(*fakeResult)}, nil
+}
+
+type fakeConn struct {
+	result *fakeResult
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{result: c.result, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	if err := c.result.record("BEGIN"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	return c.result.record("COMMIT")
+}
+
+func (c *fakeConn) Rollback() error {
+	return c.result.record("ROLLBACK")
+}
+
+type fakeStmt struct {
+	result *fakeResult
+	query  string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+// CheckNamedValue 除driver.Valuer外不转换参数，记录调用方传入的原始值
+func (s *fakeStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if v, ok := nv.Value.(driver.Valuer); ok {
+		var err error
+		nv.Value, err = v.Value()
+		return err
+	}
+	return nil
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.result.record(s.query); err != nil {
+		return nil, err
+	}
+	s.result.mu.Lock()
+	defer s.result.mu.Unlock()
+	s.result.lastExec = s.query
+	s.result.lastArgs = args
+	if s.result.rowsAffected != nil {
+		return driver.RowsAffected(s.result.rowsAffected(s.query)), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.result.record(s.query); err != nil {
+		return nil, err
+	}
+	return &fakeRows{result: s.result}, nil
+}
+
+func (s *fakeStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if s.result.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.result.delay):
+		}
+	}
+	return s.Query(nil)
+}
+
+type fakeRows struct {
+	result *fakeResult
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.result.columns
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(index int) string {
+	if index < len(r.result.types) {
+		return r.result.types[index]
+	}
+	return ""
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.pos])
+	r.pos++
+	return nil
+}
@@ -1,18 +1,50 @@
 package easydb
 
 import (
-	"fmt"
+	"database/sql"
+	"reflect"
+	"strings"
 )
 
-// 定义占位符映射
-var placeholder = map[string]string{
-	"postgres": "$%d",
-	"mysql":    "?",
-	"sqlite":   "?",
+// GetPlaceholder 生成参数占位符。index从0开始
+// mysql, sqlite3 的参数占位符是?. postgres则是 $1, $2, $3 ... 详见Dialect.Placeholder
+func GetPlaceholder(dbType string, index int) string {
+	return GetDialect(dbType).Placeholder(index)
 }
 
-// getPlaceholder 生成参数占位符
-// mysql, sqlite 的参数占位符是?. postgres则是 $1, $2, $3 ...
-func GetPlaceholder(dbType string, index int) string {
-	return fmt.Sprintf(placeholder[dbType], index+1)
+// driverPkgs 数据库驱动所在包路径与数据库类型的对应关系。
+// 驱动类型和驱动返回的错误类型，均在这些包路径下。
+var driverPkgs = []struct {
+	pkg    string
+	driver string
+}{
+	{"github.com/lib/pq", "postgres"},
+	{"github.com/jackc/pgconn", "postgres"},
+	{"github.com/jackc/pgx", "postgres"},
+	{"github.com/go-sql-driver/mysql", "mysql"},
+	{"github.com/mattn/go-sqlite3", "sqlite3"},
+	{"modernc.org/sqlite", "sqlite3"},
+	{"github.com/denisenkom/go-mssqldb", "sqlserver"},
+	{"github.com/microsoft/go-mssqldb", "sqlserver"},
+	{"github.com/godror/godror", "oracle"},
+	{"github.com/sijms/go-ora", "oracle"},
+}
+
+// getDriverNameByPkg 根据包路径获取数据库类型。未知的包路径返回空字符串。
+func getDriverNameByPkg(pkgPath string) string {
+	for _, p := range driverPkgs {
+		if strings.HasPrefix(pkgPath, p.pkg) {
+			return p.driver
+		}
+	}
+	return ""
+}
+
+// getDriverName 根据*sql.DB使用的数据库驱动，获取数据库类型。未知的数据库驱动返回空字符串。
+func getDriverName(sqldb *sql.DB) string {
+	t := reflect.TypeOf(sqldb.Driver())
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return getDriverNameByPkg(t.PkgPath())
 }
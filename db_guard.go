@@ -0,0 +1,183 @@
+package easydb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrMissingWhere 开启WriteGuard.RequireWhere后，执行没有WHERE条件的UPDATE/DELETE语句时返回
+	ErrMissingWhere = errors.New("UPDATE/DELETE语句缺少WHERE条件")
+	// ErrTooManyRowsAffected 开启WriteGuard.MaxRowsAffected后，UPDATE/DELETE语句受影响的行数超过限制时返回，语句已回滚
+	ErrTooManyRowsAffected = errors.New("受影响的行数超过限制")
+)
+
+// WriteGuard 写操作保护，防止误更新、误删除整张表的数据。使用EasyDb.SetWriteGuard设置。
+// 作用于Exec, ExecContext等执行方法，以及Query, QueryRow, GetOne, GetOneData, GetMany和查询构建器的ExecReturning中的
+// UPDATE/DELETE ... RETURNING 语句（仅检查WHERE条件）。子查询和CTE中的UPDATE/DELETE语句同样检查。
+type WriteGuard struct {
+	// RequireWhere 拒绝执行没有WHERE条件的UPDATE/DELETE语句，返回ErrMissingWhere
+	RequireWhere bool
+	// MaxRowsAffected 大于0时，UPDATE/DELETE语句在事务（或事务中的保存点）中执行，
+	// 受影响的行数超过MaxRowsAffected时回滚，返回ErrTooManyRowsAffected
+	MaxRowsAffected int64
+}
+
+// SetWriteGuard 设置写操作保护。默认不开启。
+// 示例：
+//
+//	d.SetWriteGuard(easydb.WriteGuard{RequireWhere: true, MaxRowsAffected: 1000})
+//	_, err := d.Exec("DELETE FROM users") // err为easydb.ErrMissingWhere
+func (d *EasyDb) SetWriteGuard(guard WriteGuard) {
+	d.guard = guard
+}
+
+// writeStatement parseWrite解析中的一条语句或括号中的子语句
+type writeStatement struct {
+	// verb 语句的类型。以WITH开头时，为其后的首个SELECT/INSERT/UPDATE/DELETE/MERGE
+	verb  string
+	with  bool
+	where bool
+}
+
+// parseWrite 解析SQL语句，判断是否含有UPDATE/DELETE语句，以及其中是否有缺少WHERE条件的语句。
+// 多条语句以;分隔。字符串和注释中的内容不参与判断。
+// 括号中的内容（子查询、CTE）作为独立的子语句判断，如 WITH t AS (DELETE FROM users RETURNING *) SELECT * FROM t
+func parseWrite(query, dialectName string) (write, missingWhere bool) {
+	var stack []writeStatement
+	var cur writeStatement
+	end := func(st writeStatement) {
+		if st.verb == "UPDATE" || st.verb == "DELETE" {
+			write = true
+			if !st.where {
+				missingWhere = true
+			}
+		}
+	}
+	for i := 0; i < len(query); {
+		if j := skipLiteral(query, i, dialectName); j > i {
+			i = j
+			continue
+		}
+		c := query[i]
+		switch {
+		case c == '(':
+			stack = append(stack, cur)
+			cur = writeStatement{}
+		case c == ')' && len(stack) > 0:
+			end(cur)
+			cur = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+		case c == ';' && len(stack) == 0:
+			end(cur)
+			cur = writeStatement{}
+		case isIdentChar(c):
+			j := i
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			word := strings.ToUpper(query[i:j])
+			switch {
+			case cur.verb == "" && !cur.with && word == "WITH":
+				cur.with = true
+			case cur.verb == "" && (!cur.with || word == "SELECT" || word == "INSERT" || word == "UPDATE" || word == "DELETE" || word == "MERGE"):
+				cur.verb = word
+			case word == "WHERE":
+				cur.where = true
+			}
+			i = j
+			continue
+		}
+		i++
+	}
+	// 括号未闭合时，逐层结束
+	for len(stack) > 0 {
+		end(cur)
+		cur = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+	}
+	end(cur)
+	return write, missingWhere
+}
+
+// guardQuery 查询方法执行前的检查。开启RequireWhere时，拒绝没有WHERE条件的 UPDATE/DELETE ... RETURNING 语句
+func (d *EasyDb) guardQuery(query string) error {
+	if !d.guard.RequireWhere {
+		return nil
+	}
+	if _, missingWhere := parseWrite(query, d.dialect.Name()); missingWhere {
+		return fmt.Errorf("%w: %s", ErrMissingWhere, query)
+	}
+	return nil
+}
+
+// execGuarded 按写操作保护的设置执行SQL语句
+func (d *EasyDb) execGuarded(ctx context.Context, conn sqlConn, query string, args ...interface{}) (sql.Result, error) {
+	write, missingWhere := parseWrite(query, d.dialect.Name())
+	if missingWhere && d.guard.RequireWhere {
+		return nil, fmt.Errorf("%w: %s", ErrMissingWhere, query)
+	}
+	if !write || d.guard.MaxRowsAffected <= 0 {
+		return d.execLogged(ctx, conn, query, args...)
+	}
+
+	db, ok := conn.(*sql.DB)
+	if !ok {
+		// 已在事务中，使用保存点回滚本条语句
+		return d.execLimited(ctx, conn, query, args, true)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("开始事务失败: %w", err)
+	}
+	result, err := d.execLimited(ctx, tx, query, args, false)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("提交事务失败: %w", err)
+	}
+	return result, nil
+}
+
+// guardSavepoint 在事务中检查受影响的行数时使用的保存点
+const guardSavepoint = "easydb_guard"
+
+// execLimited 执行SQL语句，检查受影响的行数是否超过MaxRowsAffected。
+// savepoint为false时，conn为新开启的事务，出错时由调用方回滚；为true时在保存点中执行，出错时回滚到保存点
+func (d *EasyDb) execLimited(ctx context.Context, conn sqlConn, query string, args []interface{}, savepoint bool) (sql.Result, error) {
+	if savepoint {
+		if _, err := conn.ExecContext(ctx, d.dialect.Savepoint(guardSavepoint)); err != nil {
+			return nil, fmt.Errorf("创建保存点失败: %w", err)
+		}
+	}
+	result, err := d.execLogged(ctx, conn, query, args...)
+	if err == nil {
+		var affected int64
+		affected, err = result.RowsAffected()
+		if err == nil && affected > d.guard.MaxRowsAffected {
+			err = fmt.Errorf("%w: %d > %d", ErrTooManyRowsAffected, affected, d.guard.MaxRowsAffected)
+		}
+	}
+	if !savepoint {
+		return result, err
+	}
+	if err != nil {
+		if _, rerr := conn.ExecContext(ctx, d.dialect.RollbackToSavepoint(guardSavepoint)); rerr != nil {
+			return nil, errors.Join(err, fmt.Errorf("回滚到保存点失败: %w", rerr))
+		}
+	}
+	if release := d.dialect.ReleaseSavepoint(guardSavepoint); release != "" {
+		if _, rerr := conn.ExecContext(ctx, release); rerr != nil && err == nil {
+			err = fmt.Errorf("释放保存点失败: %w", rerr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
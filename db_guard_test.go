@@ -0,0 +1,129 @@
+package easydb
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWrite(t *testing.T) {
+	tests := []struct {
+		query        string
+		write        bool
+		missingWhere bool
+	}{
+		{"SELECT * FROM users", false, false},
+		{"DELETE FROM users", true, true},
+		{"delete from users where id = $1", true, false},
+		{"UPDATE users SET name = 'WHERE' -- WHERE", true, true},
+		{"UPDATE users SET age = (SELECT MAX(age) FROM users WHERE id = 1)", true, true},
+		{"WITH t AS (SELECT id FROM users WHERE age > 1) DELETE FROM users", true, true},
+		{"WITH t AS (SELECT 1) INSERT INTO users (id) SELECT 1 ON CONFLICT (id) DO UPDATE SET age = 1", false, false},
+		{"INSERT INTO users (id) VALUES (1) ON DUPLICATE KEY UPDATE age = 1", false, false},
+		{"UPDATE users SET age = 1 WHERE id = 1; DELETE FROM users", true, true},
+		// 子查询和CTE中的UPDATE/DELETE语句
+		{"WITH x AS (DELETE FROM users RETURNING *) SELECT * FROM x", true, true},
+		{"WITH x AS (DELETE FROM users WHERE id = 1 RETURNING *) SELECT * FROM x", true, false},
+		{"WITH x AS (UPDATE users SET age = 1 RETURNING id) DELETE FROM logs WHERE user_id IN (SELECT id FROM x)", true, true},
+		{"SELECT * FROM (SELECT id FROM users) t", false, false},
+		{"DELETE FROM users WHERE id IN (SELECT id FROM (DELETE FROM logs RETURNING id) d)", true, true},
+	}
+	for _, tt := range tests {
+		write, missingWhere := parseWrite(tt.query, "postgres")
+		if write != tt.write || missingWhere != tt.missingWhere {
+			t.Errorf("parseWrite(%s) got: %v %v, want: %v %v", tt.query, write, missingWhere, tt.write, tt.missingWhere)
+		}
+	}
+}
+
+func TestWriteGuardRequireWhere(t *testing.T) {
+	result := &fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+	d.SetWriteGuard(WriteGuard{RequireWhere: true})
+
+	if _, err := d.Exec("DELETE FROM users"); !errors.Is(err, ErrMissingWhere) {
+		t.Errorf("Exec got: %v", err)
+	}
+	var ids []int64
+	if err := d.Delete("users").Returning("id").ExecReturning(&ids); !errors.Is(err, ErrMissingWhere) {
+		t.Errorf("ExecReturning got: %v", err)
+	}
+	if err := d.GetMany("DELETE FROM users RETURNING id", &ids); !errors.Is(err, ErrMissingWhere) {
+		t.Errorf("GetMany got: %v", err)
+	}
+	var id int64
+	if err := d.GetOne("DELETE FROM users RETURNING id", []interface{}{&id}); !errors.Is(err, ErrMissingWhere) {
+		t.Errorf("GetOne got: %v", err)
+	}
+	if err := d.GetOneData("UPDATE users SET age = 1 RETURNING id", &id); !errors.Is(err, ErrMissingWhere) {
+		t.Errorf("GetOneData got: %v", err)
+	}
+	if _, err := d.Query("DELETE FROM users RETURNING id"); !errors.Is(err, ErrMissingWhere) {
+		t.Errorf("Query got: %v", err)
+	}
+	if err := d.QueryRow("DELETE FROM users RETURNING id").Scan(&id); !errors.Is(err, ErrMissingWhere) {
+		t.Errorf("QueryRow got: %v", err)
+	}
+	if err := d.GetMany("WITH x AS (DELETE FROM users RETURNING id) SELECT id FROM x", &ids); !errors.Is(err, ErrMissingWhere) {
+		t.Errorf("GetMany CTE got: %v", err)
+	}
+	if log := result.executed(); len(log) != 0 {
+		t.Errorf("rejected statements executed: %v", log)
+	}
+
+	if err := d.Delete("users").Where("id = ?", 1).Returning("id").ExecReturning(&ids); err != nil || len(ids) != 1 {
+		t.Errorf("ExecReturning with WHERE got: %v %v", ids, err)
+	}
+}
+
+func TestWriteGuardMaxRowsAffected(t *testing.T) {
+	result := &fakeResult{rowsAffected: func(query string) int64 {
+		if strings.HasPrefix(query, "DELETE") {
+			return 5
+		}
+		return 0
+	}}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+	d.SetWriteGuard(WriteGuard{MaxRowsAffected: 3})
+
+	query := "DELETE FROM users WHERE age > $1"
+	if _, err := d.Exec(query, 1); !errors.Is(err, ErrTooManyRowsAffected) {
+		t.Errorf("Exec got: %v", err)
+	}
+	if log, want := result.executed(), []string{"BEGIN", query, "ROLLBACK"}; !reflect.DeepEqual(log, want) {
+		t.Errorf("Exec executed: %v, want: %v", log, want)
+	}
+
+	d.SetWriteGuard(WriteGuard{MaxRowsAffected: 5})
+	if _, err := d.Exec(query, 1); err != nil {
+		t.Errorf("Exec got: %v", err)
+	}
+	if log, want := result.executed(), []string{"BEGIN", query, "COMMIT"}; !reflect.DeepEqual(log, want) {
+		t.Errorf("Exec executed: %v, want: %v", log, want)
+	}
+
+	// 事务中使用保存点回滚本条语句，事务仍可继续使用
+	d.SetWriteGuard(WriteGuard{MaxRowsAffected: 3})
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec(query, 1); !errors.Is(err, ErrTooManyRowsAffected) {
+		t.Errorf("tx.Exec got: %v", err)
+	}
+	if _, err := tx.Exec("INSERT INTO users (name) VALUES ($1)", "a"); err != nil {
+		t.Errorf("tx.Exec after rollback to savepoint got: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"BEGIN", "SAVEPOINT easydb_guard", query, "ROLLBACK TO SAVEPOINT easydb_guard", "RELEASE SAVEPOINT easydb_guard",
+		"INSERT INTO users (name) VALUES ($1)", "COMMIT"}
+	if log := result.executed(); !reflect.DeepEqual(log, want) {
+		t.Errorf("tx executed: %v, want: %v", log, want)
+	}
+}
@@ -0,0 +1,106 @@
+package easydb
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// JSON 以JSON格式存储在数据库中的值，如postgres的json, jsonb和mysql的JSON类型的列。
+// 写入时序列化为JSON文本，读取时反序列化到V，NULL时V为零值。
+// 用于结构体字段时效果同db标签的json选项，也可以直接作为Exec等方法的参数和GetOneData的dest。
+// 示例：
+//
+//	type User struct {
+//		ID       int64                  `db:"id,pk"`
+//		Settings easydb.JSON[Settings]  `db:"settings"`
+//	}
+type JSON[T any] struct {
+	V T
+}
+
+// NewJSON 创建JSON值
+func NewJSON[T any](v T) JSON[T] {
+	return JSON[T]{V: v}
+}
+
+// Value 实现driver.Valuer接口，序列化为JSON文本
+func (j JSON[T]) Value() (driver.Value, error) {
+	return marshalJSON(j.V)
+}
+
+// Scan 实现sql.Scanner接口，从JSON文本反序列化
+func (j *JSON[T]) Scan(src interface{}) error {
+	var zero T
+	j.V = zero
+	return unmarshalJSON(src, &j.V)
+}
+
+// MarshalJSON 实现json.Marshaler接口，序列化V
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.V)
+}
+
+// UnmarshalJSON 实现json.Unmarshaler接口，反序列化到V
+func (j *JSON[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &j.V)
+}
+
+// marshalJSON 序列化为写入数据库的JSON文本。
+// 使用string而不是[]byte，避免部分驱动（如lib/pq）将[]byte作为二进制数据写入
+func marshalJSON(v interface{}) (driver.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("序列化JSON失败: %w", err)
+	}
+	return string(b), nil
+}
+
+// unmarshalJSON 将驱动返回的JSON文本反序列化到dest。src为nil时dest保持不变
+func unmarshalJSON(src interface{}, dest interface{}) error {
+	var data []byte
+	switch s := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = s
+	case string:
+		data = []byte(s)
+	default:
+		return fmt.Errorf("无法将%T反序列化为JSON", src)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("反序列化JSON失败: %w", err)
+	}
+	return nil
+}
+
+// jsonArg 带json选项的结构体字段的值，写入时序列化为JSON文本
+type jsonArg struct {
+	v interface{}
+}
+
+// Value 实现driver.Valuer接口
+func (a jsonArg) Value() (driver.Value, error) {
+	return marshalJSON(a.v)
+}
+
+// String 用于日志输出
+func (a jsonArg) String() string {
+	return fmt.Sprint(a.v)
+}
+
+// jsonScanner 扫描JSON文本到带json选项的结构体字段v。NULL为零值
+type jsonScanner struct {
+	v reflect.Value
+}
+
+func (s jsonScanner) Scan(src interface{}) error {
+	s.v.SetZero()
+	return unmarshalJSON(src, s.v.Addr().Interface())
+}
@@ -0,0 +1,74 @@
+package easydb
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type jsonSettings struct {
+	Theme string `json:"theme"`
+	Size  int    `json:"size"`
+}
+
+type jsonUser struct {
+	ID       int64                  `db:"id,pk"`
+	Settings jsonSettings           `db:"settings,json"`
+	Meta     map[string]interface{} `db:"meta,json"`
+	Tags     []string               `db:"tags,json"`
+	Extra    *jsonSettings          `db:"extra,json"`
+	Profile  JSON[jsonSettings]     `db:"profile"`
+}
+
+func TestJSONColumns(t *testing.T) {
+	result := &fakeResult{
+		columns: []string{"id", "settings", "meta", "tags", "extra", "profile"},
+		types:   []string{"INT", "JSONB", "JSON", "JSON", "JSON", "JSONB"},
+		rows: [][]driver.Value{
+			{int64(1), []byte(`{"theme":"dark","size":2}`), `{"a":1,"b":[true]}`, []byte(`["x","y"]`), []byte(`{"theme":"light"}`), []byte(`{"size":3}`)},
+			{int64(2), nil, nil, nil, nil, nil},
+		},
+	}
+	d := newFakeDb(t, result)
+	var users []jsonUser
+	if err := d.GetMany("SELECT ...", &users); err != nil {
+		t.Fatal(err)
+	}
+	want := jsonUser{
+		ID:       1,
+		Settings: jsonSettings{Theme: "dark", Size: 2},
+		Meta:     map[string]interface{}{"a": float64(1), "b": []interface{}{true}},
+		Tags:     []string{"x", "y"},
+		Extra:    &jsonSettings{Theme: "light"},
+		Profile:  NewJSON(jsonSettings{Size: 3}),
+	}
+	if len(users) != 2 || !reflect.DeepEqual(users[0], want) || !reflect.DeepEqual(users[1], jsonUser{ID: 2}) {
+		t.Errorf("GetMany got: %+v", users)
+	}
+
+	var maps []map[string]interface{}
+	if err := d.GetMany("SELECT ...", &maps); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(maps[0]["meta"], want.Meta) || !reflect.DeepEqual(maps[0]["tags"], []interface{}{"x", "y"}) || maps[1]["meta"] != nil {
+		t.Errorf("GetMany map got: %+v", maps)
+	}
+
+	d2 := newFakeDb(t, &fakeResult{columns: []string{"profile"}, rows: [][]driver.Value{{`{"theme":"blue"}`}}})
+	var profile JSON[jsonSettings]
+	if err := d2.GetOneData("SELECT profile FROM users", &profile); err != nil || profile.V.Theme != "blue" {
+		t.Errorf("GetOneData got: %+v %v", profile, err)
+	}
+
+	u := jsonUser{ID: 1, Settings: jsonSettings{Theme: "dark"}, Tags: []string{"a"}, Profile: NewJSON(jsonSettings{Size: 1})}
+	if _, err := d.UpdateStruct("users", &u); err != nil {
+		t.Fatal(err)
+	}
+	result.mu.Lock()
+	args := result.lastArgs
+	result.mu.Unlock()
+	wantArgs := []driver.Value{`{"theme":"dark","size":0}`, nil, `["a"]`, nil, `{"theme":"","size":1}`, int64(1)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("UpdateStruct args got: %#v", args)
+	}
+}
@@ -0,0 +1,325 @@
+package easydb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// MappingOptions 查询结果的列与结构体字段的对应规则。使用EasyDb.SetMappingOptions设置。
+// 默认只匹配带db标签的字段，列名区分大小写，查询结果中有无对应字段的列时返回错误。
+type MappingOptions struct {
+	// IgnoreUnknownColumns 忽略无对应结构体字段的列，不返回错误
+	IgnoreUnknownColumns bool
+	// SnakeCase 不带db标签的导出字段，以字段名的snake_case形式作为列名，如 WalletBalance 对应 wallet_balance
+	SnakeCase bool
+	// CaseInsensitive 列名匹配不区分大小写
+	CaseInsensitive bool
+	// NameMapper 自定义不带db标签的导出字段对应的列名，优先于SnakeCase。返回空字符串时忽略该字段
+	NameMapper func(fieldName string) string
+	// Strict 严格模式。结构体中有字段无对应的列时，返回错误。包括嵌入的结构体和嵌套的结构体中的字段
+	Strict bool
+}
+
+// structMapper 按MappingOptions获取结构体类型与查询结果列的对应关系，并缓存
+type structMapper struct {
+	opts MappingOptions
+	// cache 缓存结构体类型的列名与字段的对应关系(*typeMapping)，以结构体类型为键，数量不超过程序中的结构体类型。
+	// 每次查询按结果的列查找字段，扫描每行数据时直接按序号取字段。
+	cache sync.Map
+}
+
+// defaultMapper 未调用SetMappingOptions时使用的默认规则
+var defaultMapper = &structMapper{}
+
+// SetMappingOptions 设置查询结果的列与结构体字段的对应规则。作用于GetOne, GetOneData, GetMany等扫描到结构体的方法。
+// 示例：
+//
+//	d.SetMappingOptions(easydb.MappingOptions{IgnoreUnknownColumns: true, SnakeCase: true})
+func (d *EasyDb) SetMappingOptions(opts MappingOptions) {
+	d.mapper = &structMapper{opts: opts}
+}
+
+// MappingOptions 获取查询结果的列与结构体字段的对应规则
+func (d *EasyDb) MappingOptions() MappingOptions {
+	return d.getMapper().opts
+}
+
+func (d *EasyDb) getMapper() *structMapper {
+	if d.mapper == nil {
+		return defaultMapper
+	}
+	return d.mapper
+}
+
+// fieldMapping 结构体类型与查询结果列的对应关系
+type fieldMapping struct {
+	// fields 各列对应的结构体字段的索引路径。为nil时忽略该列
+	fields [][]int
+	// kinds 各列对应的字段的扫描方式
+	kinds []scanKind
+	// types 各列对应的字段的类型，忽略的列为nil
+	types []reflect.Type
+}
+
+// typeMapping 结构体类型的列名与字段的对应关系
+type typeMapping struct {
+	// names 列名（按MappingOptions处理大小写）对应的字段
+	names map[string]structField
+	// paths 字段的索引路径对应的列名。同一字段对应多个列名时，为其中最小的列名，用于Strict模式的错误信息
+	paths map[string]string
+}
+
+// fieldName 不带db标签的字段对应的列名。为nil时忽略不带db标签的字段
+func (m *structMapper) fieldName() func(string) string {
+	if m.opts.NameMapper != nil {
+		return m.opts.NameMapper
+	}
+	if m.opts.SnakeCase {
+		return toSnakeCase
+	}
+	return nil
+}
+
+func (m *structMapper) columnKey(name string) string {
+	if m.opts.CaseInsensitive {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// getTypeMapping 获取结构体类型t的列名与字段的对应关系
+func (m *structMapper) getTypeMapping(t reflect.Type) *typeMapping {
+	if tm, ok := m.cache.Load(t); ok {
+		return tm.(*typeMapping)
+	}
+	tm := &typeMapping{names: make(map[string]structField), paths: make(map[string]string)}
+	for name, f := range structColumns(t, m.fieldName()) {
+		name = m.columnKey(name)
+		// 不区分大小写时，同名的列同样外层结构体的字段优先
+		if old, ok := tm.names[name]; ok && len(old.index) <= len(f.index) {
+			continue
+		}
+		tm.names[name] = f
+	}
+	// 同一字段可能对应多个列名，如嵌套结构体的 author.id 和 author_id，按索引路径去重
+	for name, f := range tm.names {
+		path := fmt.Sprint(f.index)
+		if tm.paths[path] == "" || name < tm.paths[path] {
+			tm.paths[path] = name
+		}
+	}
+	m.cache.Store(t, tm)
+	return tm
+}
+
+// getFieldMapping 获取结构体类型t与查询结果列columns的对应关系
+func (m *structMapper) getFieldMapping(t reflect.Type, columns []string) (*fieldMapping, error) {
+	tm := m.getTypeMapping(t)
+	fm := &fieldMapping{fields: make([][]int, len(columns)), kinds: make([]scanKind, len(columns)), types: make([]reflect.Type, len(columns))}
+	mapped := make(map[string]bool, len(columns))
+	for i, col := range columns {
+		f, ok := tm.names[m.columnKey(col)]
+		if !ok {
+			if m.opts.IgnoreUnknownColumns {
+				continue
+			}
+			return nil, fmt.Errorf("列 %s 无对应的结构体字段", col)
+		}
+		fm.fields[i] = f.index
+		fm.kinds[i] = scanKindOf(f.typ)
+		if f.tag.json {
+			fm.kinds[i] = scanJSON
+		}
+		fm.types[i] = f.typ
+		mapped[fmt.Sprint(f.index)] = true
+	}
+	if m.opts.Strict {
+		var cols []string
+		for path, name := range tm.paths {
+			if !mapped[path] {
+				cols = append(cols, name)
+			}
+		}
+		if len(cols) > 0 {
+			sort.Strings(cols)
+			return nil, fmt.Errorf("结构体%s的字段无对应的列: %s", t, strings.Join(cols, ", "))
+		}
+	}
+	return fm, nil
+}
+
+// discardColumn 忽略的列的扫描目标
+type discardColumn struct{}
+
+func (discardColumn) Scan(interface{}) error {
+	return nil
+}
+
+// structScan 扫描同一次查询的各行数据到结构体
+type structScan struct {
+	mapping *fieldMapping
+	dest    []interface{}
+	nulls   *nullScan
+	// decodes 各列的自定义解码函数，没有注册转换函数时为nil
+	decodes []decodeFunc
+	// nested 查询结果中有对应列的嵌套结构体指针，外层的在前
+	nested []nestedPointer
+	// owners 各列所在的最内层的嵌套结构体指针在nested中的序号，不在嵌套结构体指针中为-1
+	owners []int
+	// nullFlags 嵌套结构体指针中实现了sql.Scanner的扫描目标，记录值是否为NULL，见flagNull
+	nullFlags []bool
+}
+
+// nestedPointer 嵌套的结构体指针字段，如 Author *Author。对应的列全部为NULL时（如LEFT JOIN没有匹配的数据）为nil
+type nestedPointer struct {
+	// index 字段的索引路径
+	index []int
+	typ   reflect.Type
+	// parent 外层的嵌套结构体指针在nested中的序号，没有时为-1
+	parent int
+}
+
+// nestedPointers 获取各列的索引路径上的嵌套结构体指针（不含嵌入的结构体指针），以及各列所在的最内层的嵌套结构体指针
+func nestedPointers(t reflect.Type, fields [][]int) ([]nestedPointer, []int) {
+	var nested []nestedPointer
+	positions := make(map[string]int)
+	owners := make([]int, len(fields))
+	for i, index := range fields {
+		owners[i] = -1
+		st := t
+		for j := 0; j < len(index)-1; j++ {
+			f := st.Field(index[j])
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+				if !f.Anonymous {
+					key := fmt.Sprint(index[:j+1])
+					n, ok := positions[key]
+					if !ok {
+						n = len(nested)
+						positions[key] = n
+						nested = append(nested, nestedPointer{index: index[:j+1], typ: f.Type, parent: owners[i]})
+					}
+					owners[i] = n
+				}
+			}
+			st = ft
+		}
+	}
+	return nested, owners
+}
+
+// newStructScan 获取查询结果与结构体类型t的对应关系，用于扫描各行数据
+func (d *EasyDb) newStructScan(rows *sql.Rows, t reflect.Type) (*structScan, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("获取列失败: %w", err)
+	}
+	mapping, err := d.getMapper().getFieldMapping(t, cols)
+	if err != nil {
+		return nil, err
+	}
+	s := &structScan{mapping: mapping, dest: make([]interface{}, len(cols))}
+	if !d.nullAsError {
+		s.nulls = newNullScan(len(cols))
+	}
+	if s.decodes, err = d.typeDecoders(rows, mapping.types); err != nil {
+		return nil, err
+	}
+	if s.nested, s.owners = nestedPointers(t, mapping.fields); len(s.nested) > 0 {
+		s.nullFlags = make([]bool, len(cols))
+	}
+	return s, nil
+}
+
+// scan 扫描当前行数据到结构体v。
+// 嵌套的结构体指针中的列，先扫描到新分配的结构体，有不为NULL的列时再赋值给字段，否则字段为nil
+func (s *structScan) scan(rows *sql.Rows, v reflect.Value) error {
+	var temps []reflect.Value
+	if len(s.nested) > 0 {
+		temps = make([]reflect.Value, len(s.nested))
+		for k, n := range s.nested {
+			temps[k] = reflect.New(n.typ.Elem())
+		}
+	}
+	for i, index := range s.mapping.fields {
+		if index == nil {
+			s.dest[i] = discardColumn{}
+			continue
+		}
+		var decode decodeFunc
+		if s.decodes != nil {
+			decode = s.decodes[i]
+		}
+		field := v
+		if len(s.nested) > 0 && s.owners[i] >= 0 {
+			owner := s.owners[i]
+			field, index = temps[owner].Elem(), index[len(s.nested[owner].index):]
+		}
+		s.dest[i] = scanTarget(fieldByIndexAlloc(field, index), s.mapping.kinds[i], i, s.nulls, decode)
+		if len(s.nested) > 0 && s.owners[i] >= 0 {
+			s.dest[i] = flagNull(s.dest[i], &s.nullFlags[i])
+		}
+	}
+	if err := rows.Scan(s.dest...); err != nil {
+		return err
+	}
+	if s.nulls != nil {
+		s.nulls.finish()
+	}
+	if len(s.nested) > 0 {
+		s.setNested(v, temps)
+	}
+	return nil
+}
+
+// setNested 将扫描到的嵌套结构体赋值给字段。对应的列全部为NULL时，字段为nil
+func (s *structScan) setNested(v reflect.Value, temps []reflect.Value) {
+	notNull := make([]bool, len(s.nested))
+	for i, owner := range s.owners {
+		if owner < 0 || isNullTarget(s.dest[i]) {
+			continue
+		}
+		for k := owner; k >= 0 && !notNull[k]; k = s.nested[k].parent {
+			notNull[k] = true
+		}
+	}
+	for k, n := range s.nested {
+		parent, index := v, n.index
+		if n.parent >= 0 {
+			parent, index = temps[n.parent].Elem(), index[len(s.nested[n.parent].index):]
+		}
+		field := fieldByIndexAlloc(parent, index)
+		if notNull[k] {
+			field.Set(temps[k])
+		} else {
+			field.SetZero()
+		}
+	}
+}
+
+// toSnakeCase 将字段名转换为snake_case形式，如 WalletBalance => wallet_balance, UserID => user_id, HTTPServer => http_server
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
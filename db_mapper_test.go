@@ -0,0 +1,295 @@
+package easydb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newUserRows(n int) *fakeResult {
+	result := &fakeResult{columns: []string{"id", "name", "age", "wallet_balance"}}
+	for i := 1; i <= n; i++ {
+		result.rows = append(result.rows, []driver.Value{int64(i), fmt.Sprintf("user%d", i), int64(20 + i%50), float64(i) * 1.5})
+	}
+	return result
+}
+
+func TestFieldMapping(t *testing.T) {
+	d := newFakeDb(t, newUserRows(3))
+	var users []User
+	if err := d.GetMany("SELECT id, name, age, wallet_balance FROM users", &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 3 || users[2] != (User{ID: 3, Name: "user3", Age: 23, WalletBalance: 4.5}) {
+		t.Errorf("GetMany got: %+v", users)
+	}
+	var user User
+	if err := d.GetOneData("SELECT id, name, age, wallet_balance FROM users", &user); err != nil {
+		t.Fatal(err)
+	}
+	if user != users[0] {
+		t.Errorf("GetOneData got: %+v", user)
+	}
+
+	mapper := &structMapper{}
+	m1, err := mapper.getFieldMapping(reflect.TypeOf(User{}), []string{"name", "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, _ := mapper.getFieldMapping(reflect.TypeOf(User{}), []string{"id"})
+	if !reflect.DeepEqual(m1.fields, [][]int{{1}, {0}}) || !reflect.DeepEqual(m2.fields, [][]int{{0}}) {
+		t.Errorf("getFieldMapping got: %v %v", m1, m2)
+	}
+	// 缓存以结构体类型为键，不随查询的列的组合增长
+	cached := 0
+	mapper.cache.Range(func(key, value interface{}) bool {
+		cached++
+		return true
+	})
+	if cached != 1 {
+		t.Errorf("mapper cache size got: %d", cached)
+	}
+	if _, err = defaultMapper.getFieldMapping(reflect.TypeOf(User{}), []string{"id", "unknown"}); err == nil {
+		t.Error("getFieldMapping should fail on unknown column")
+	}
+}
+
+// legacyScanRowToStruct 使用字段映射缓存之前的实现：每行数据的每一列，都遍历结构体所有字段并读取标签
+func legacyScanRowToStruct(rows *sql.Rows, dest interface{}) error {
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	destVal := reflect.ValueOf(dest).Elem()
+	fields := make([]interface{}, len(cols))
+	for i, col := range cols {
+		fieldFound := false
+		for j := 0; j < destVal.NumField(); j++ {
+			if destVal.Type().Field(j).Tag.Get("db") == col {
+				fields[i] = destVal.Field(j).Addr().Interface()
+				fieldFound = true
+				break
+			}
+		}
+		if !fieldFound {
+			return fmt.Errorf("列 %s 无对应的结构体字段", col)
+		}
+	}
+	return rows.Scan(fields...)
+}
+
+func benchmarkScanStruct(b *testing.B, scan func(d *EasyDb, rows *sql.Rows, dest interface{}) error) {
+	d := newFakeDb(b, newUserRows(1000))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := d.db.Query("SELECT id, name, age, wallet_balance FROM users")
+		if err != nil {
+			b.Fatal(err)
+		}
+		for rows.Next() {
+			var user User
+			if err := scan(d, rows, &user); err != nil {
+				b.Fatal(err)
+			}
+		}
+		rows.Close()
+	}
+}
+
+// BenchmarkScanStruct 扫描1000行数据到结构体，使用字段映射缓存
+func BenchmarkScanStruct(b *testing.B) {
+	benchmarkScanStruct(b, func(d *EasyDb, rows *sql.Rows, dest interface{}) error {
+		return d.scanRowToStruct(rows, dest)
+	})
+}
+
+// BenchmarkScanStructLegacy 扫描1000行数据到结构体，使用缓存之前的实现
+func BenchmarkScanStructLegacy(b *testing.B) {
+	benchmarkScanStruct(b, func(d *EasyDb, rows *sql.Rows, dest interface{}) error {
+		return legacyScanRowToStruct(rows, dest)
+	})
+}
+
+// BenchmarkGetManyStruct GetMany扫描1000行数据到结构体切片
+func BenchmarkGetManyStruct(b *testing.B) {
+	d := newFakeDb(b, newUserRows(1000))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var users []User
+		if err := d.GetMany("SELECT id, name, age, wallet_balance FROM users", &users); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type baseModel struct {
+	ID        int64  `db:"id,pk"`
+	CreatedAt string `db:"created_at,readonly"`
+}
+
+type mapperAuthor struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+type mapperPost struct {
+	baseModel
+	Title    string        `db:"title"`
+	Author   *mapperAuthor `db:"author"`
+	Reviewer mapperAuthor  `db:"reviewer"`
+	Ignored  *mapperAuthor `db:"ignored"`
+}
+
+func TestNestedStructScan(t *testing.T) {
+	d := newFakeDb(t, &fakeResult{
+		columns: []string{"id", "created_at", "title", "author.id", "author_name", "reviewer_name"},
+		rows: [][]driver.Value{
+			{int64(1), "2024-01-01", "Hello", int64(7), "Tom", "Ann"},
+			{int64(2), "2024-01-02", "World", int64(8), "Jerry", "Bob"},
+			// LEFT JOIN没有匹配的作者
+			{int64(3), "2024-01-03", "Empty", nil, nil, "Bob"},
+		},
+	})
+	var posts []*mapperPost
+	if err := d.GetMany("SELECT ...", &posts); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("GetMany got %d rows", len(posts))
+	}
+	p := posts[1]
+	if p.ID != 2 || p.CreatedAt != "2024-01-02" || p.Title != "World" || p.Reviewer.Name != "Bob" {
+		t.Errorf("GetMany got: %+v", p)
+	}
+	if p.Author == nil || *p.Author != (mapperAuthor{ID: 8, Name: "Jerry"}) || p.Ignored != nil {
+		t.Errorf("GetMany nested pointer got: %+v %+v", p.Author, p.Ignored)
+	}
+	if posts[2].Author != nil || posts[2].Title != "Empty" {
+		t.Errorf("GetMany LEFT JOIN NULL got: %+v", posts[2])
+	}
+	// 已有数据的嵌套结构体指针，对应的列全部为NULL时同样为nil
+	d2 := newFakeDb(t, &fakeResult{
+		columns: []string{"id", "title", "author.id", "author_name"},
+		rows:    [][]driver.Value{{int64(3), "Empty", nil, nil}},
+	})
+	post := mapperPost{Author: &mapperAuthor{ID: 1}}
+	if err := d2.GetOneData("SELECT ...", &post); err != nil || post.Author != nil || post.Title != "Empty" {
+		t.Errorf("GetOneData nested pointer got: %+v %v", post.Author, err)
+	}
+
+	fields := getStructFields(reflect.TypeOf(mapperPost{}))
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.tag.name)
+	}
+	if !reflect.DeepEqual(names, []string{"id", "created_at", "title"}) {
+		t.Errorf("getStructFields got: %v", names)
+	}
+}
+
+type mapperCompany struct {
+	ID int64 `db:"id"`
+}
+
+type mapperEditor struct {
+	Name    sql.NullString `db:"name"`
+	Company *mapperCompany `db:"company"`
+}
+
+type mapperArticle struct {
+	ID     int64         `db:"id"`
+	Editor *mapperEditor `db:"editor"`
+}
+
+func TestNestedPointerNull(t *testing.T) {
+	d := newFakeDb(t, &fakeResult{
+		columns: []string{"id", "editor_name", "editor_company_id"},
+		rows: [][]driver.Value{
+			{int64(1), "Tom", int64(5)},
+			{int64(2), "Ann", nil},
+			{int64(3), nil, nil},
+			{int64(4), nil, int64(6)},
+		},
+	})
+	var articles []mapperArticle
+	if err := d.GetMany("SELECT ...", &articles); err != nil {
+		t.Fatal(err)
+	}
+	want := []mapperArticle{
+		{ID: 1, Editor: &mapperEditor{Name: sql.NullString{String: "Tom", Valid: true}, Company: &mapperCompany{ID: 5}}},
+		{ID: 2, Editor: &mapperEditor{Name: sql.NullString{String: "Ann", Valid: true}}},
+		{ID: 3},
+		{ID: 4, Editor: &mapperEditor{Company: &mapperCompany{ID: 6}}},
+	}
+	if !reflect.DeepEqual(articles, want) {
+		t.Errorf("GetMany got: %+v", articles)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"ID":            "id",
+		"Name":          "name",
+		"WalletBalance": "wallet_balance",
+		"UserID":        "user_id",
+		"HTTPServer":    "http_server",
+		"Address2":      "address2",
+		"V2Token":       "v2_token",
+	}
+	for in, want := range cases {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%s) got: %s, want: %s", in, got, want)
+		}
+	}
+}
+
+type mapperUser struct {
+	ID            int64 `db:"id"`
+	Name          string
+	WalletBalance float64
+	Remark        string `db:"-"`
+}
+
+func TestMappingOptions(t *testing.T) {
+	result := &fakeResult{
+		columns: []string{"ID", "name", "wallet_balance", "extra"},
+		rows:    [][]driver.Value{{int64(1), "Tom", 2.5, "x"}},
+	}
+	d := newFakeDb(t, result)
+	var users []mapperUser
+	if err := d.GetMany("SELECT ...", &users); err == nil {
+		t.Error("GetMany should fail with default options")
+	}
+
+	d.SetMappingOptions(MappingOptions{IgnoreUnknownColumns: true, SnakeCase: true, CaseInsensitive: true})
+	if err := d.GetMany("SELECT ...", &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0] != (mapperUser{ID: 1, Name: "Tom", WalletBalance: 2.5}) {
+		t.Errorf("GetMany got: %+v", users)
+	}
+
+	d.SetMappingOptions(MappingOptions{IgnoreUnknownColumns: true, CaseInsensitive: true, NameMapper: strings.ToUpper})
+	var user mapperUser
+	if err := d.GetOneData("SELECT ...", &user); err != nil {
+		t.Fatal(err)
+	}
+	if user != (mapperUser{ID: 1, Name: "Tom"}) {
+		t.Errorf("GetOneData got: %+v", user)
+	}
+
+	d.SetMappingOptions(MappingOptions{IgnoreUnknownColumns: true, Strict: true})
+	err := d.GetOneData("SELECT ...", &user)
+	if err == nil || !strings.Contains(err.Error(), "id") {
+		t.Errorf("GetOneData strict mode got: %v", err)
+	}
+	d.SetMappingOptions(MappingOptions{IgnoreUnknownColumns: true, SnakeCase: true, CaseInsensitive: true, Strict: true})
+	if err = d.GetOneData("SELECT ...", &user); err != nil {
+		t.Errorf("GetOneData strict mode got: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package easydb
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+// SetNullAsError 设置查询结果为NULL，而接收的变量不能表示NULL时（如int, string, float64）的处理方式。
+// 默认写入零值。开启后返回错误。
+//
+// 各类接收变量对NULL的处理：
+//
+//	int, string等基本类型和time.Time，以及结构体中此类字段：零值，开启SetNullAsError后返回错误
+//	指针类型，如*int, *string, *time.Time：nil
+//	sql.NullString等sql.Null*类型和sql.Null[T]：Valid为false
+//	interface{}，以及map[string]interface{}中的值：nil
+//	[]byte, json.RawMessage等字节切片和[]string, []int64等数组：nil
+//	实现encoding.TextUnmarshaler的类型和字节数组：零值，开启SetNullAsError后返回错误
+//
+// 作用于GetOne, GetOneData, GetMany等查询方法。
+func (d *EasyDb) SetNullAsError(enable bool) {
+	d.nullAsError = enable
+}
+
+// nullScan 将NULL扫描为零值。接收的变量不能表示NULL时，先扫描到临时的指针（**T），
+// 扫描后非NULL的值写入变量，NULL写入零值。
+type nullScan struct {
+	// holders 各列的临时指针，同一列在多行之间复用
+	holders []reflect.Value
+	// targets 各列对应的接收变量，不需要处理NULL的列为无效值
+	targets []reflect.Value
+}
+
+func newNullScan(columnCount int) *nullScan {
+	return &nullScan{
+		holders: make([]reflect.Value, columnCount),
+		targets: make([]reflect.Value, columnCount),
+	}
+}
+
+// target 获取第i列的扫描目标。v为可寻址的接收变量，扫描方式须为scanNotNull
+func (s *nullScan) target(i int, v reflect.Value) interface{} {
+	h := s.holders[i]
+	if !h.IsValid() || h.Type().Elem().Elem() != v.Type() {
+		h = reflect.New(reflect.PointerTo(v.Type()))
+		s.holders[i] = h
+	} else {
+		h.Elem().SetZero()
+	}
+	s.targets[i] = v
+	return h.Interface()
+}
+
+// finish 扫描完成后，将临时指针中的值写入接收变量
+func (s *nullScan) finish() {
+	for i, v := range s.targets {
+		if !v.IsValid() {
+			continue
+		}
+		if p := s.holders[i].Elem(); p.IsNil() {
+			v.SetZero()
+		} else {
+			v.Set(p.Elem())
+		}
+		s.targets[i] = reflect.Value{}
+	}
+}
+
+// nullFlag 包装实现了sql.Scanner的扫描目标，扫描时记录值是否为NULL
+type nullFlag struct {
+	sql.Scanner
+	null *bool
+}
+
+func (f nullFlag) Scan(src interface{}) error {
+	*f.null = src == nil
+	return f.Scanner.Scan(src)
+}
+
+// flagNull 包装扫描目标，用于扫描后由isNullTarget判断值是否为NULL。
+// 实现了sql.Scanner的目标记录到*null，其他目标（指针）扫描后根据其指向的值判断
+func flagNull(target interface{}, null *bool) interface{} {
+	if s, ok := target.(sql.Scanner); ok {
+		return nullFlag{Scanner: s, null: null}
+	}
+	return target
+}
+
+// isNullTarget 判断扫描到target的值是否为NULL。target为flagNull的返回值
+func isNullTarget(target interface{}) bool {
+	if f, ok := target.(nullFlag); ok {
+		return *f.null
+	}
+	v := reflect.ValueOf(target).Elem()
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return v.IsNil()
+	}
+	// 不能接收NULL的变量，遇到NULL时扫描已返回错误
+	return false
+}
@@ -0,0 +1,80 @@
+package easydb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+type nullUser struct {
+	ID        int64           `db:"id"`
+	Name      string          `db:"name"`
+	Balance   float64         `db:"balance"`
+	Nickname  *string         `db:"nickname"`
+	Age       *int64          `db:"age"`
+	LoginAt   *time.Time      `db:"login_at"`
+	Email     sql.NullString  `db:"email"`
+	Score     sql.Null[int64] `db:"score"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+func TestNullScan(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	d := newFakeDb(t, &fakeResult{
+		columns: []string{"id", "name", "balance", "nickname", "age", "login_at", "email", "score", "created_at"},
+		rows: [][]driver.Value{
+			{int64(1), "Tom", 1.5, "tommy", int64(20), now, "tom@example.com", int64(90), now},
+			{int64(2), nil, nil, nil, nil, nil, nil, nil, nil},
+		},
+	})
+	var users []nullUser
+	if err := d.GetMany("SELECT ...", &users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("GetMany got %d rows", len(users))
+	}
+	u := users[0]
+	if u.Name != "Tom" || u.Balance != 1.5 || u.Nickname == nil || *u.Nickname != "tommy" || u.Age == nil || *u.Age != 20 ||
+		u.LoginAt == nil || !u.LoginAt.Equal(now) || u.Email.String != "tom@example.com" || u.Score.V != 90 || !u.CreatedAt.Equal(now) {
+		t.Errorf("GetMany got: %+v", u)
+	}
+	if users[1] != (nullUser{ID: 2}) {
+		t.Errorf("GetMany NULL row got: %+v", users[1])
+	}
+
+	var ids []int
+	var names []string
+	var balances []float64
+	for _, dest := range []interface{}{&ids, &names, &balances} {
+		d1 := newFakeDb(t, &fakeResult{columns: []string{"v"}, rows: [][]driver.Value{{"2"}, {nil}}})
+		if err := d1.GetMany("SELECT ...", dest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ids[0] != 2 || ids[1] != 0 || names[1] != "" || balances[0] != 2 || balances[1] != 0 {
+		t.Errorf("GetMany scalar got: %v %v %v", ids, names, balances)
+	}
+
+	user := nullUser{Name: "old"}
+	d2 := newFakeDb(t, &fakeResult{columns: []string{"name", "nickname"}, rows: [][]driver.Value{{nil, nil}}})
+	if err := d2.GetOneData("SELECT ...", &user); err != nil || user.Name != "" || user.Nickname != nil {
+		t.Errorf("GetOneData got: %+v %v", user, err)
+	}
+	name, nickname := "old", new(string)
+	if err := d2.GetOne("SELECT ...", []interface{}{&name, &nickname}); err != nil || name != "" || nickname != nil {
+		t.Errorf("GetOne got: %q %v %v", name, nickname, err)
+	}
+
+	d2.SetNullAsError(true)
+	if err := d2.GetOneData("SELECT ...", &user); err == nil {
+		t.Error("GetOneData should fail on NULL with SetNullAsError")
+	}
+	if err := d2.GetOne("SELECT ...", []interface{}{&name, &nickname}); err == nil {
+		t.Error("GetOne should fail on NULL with SetNullAsError")
+	}
+	if err := d2.GetMany("SELECT ...", &names); err == nil {
+		t.Error("GetMany should fail on NULL with SetNullAsError")
+	}
+}
@@ -1,22 +1,53 @@
 package easydb
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
 )
 
-// GetOneData 根据where条件查询单条数据，支持结构体指针或map接收结果
+// ErrNotFound 开启EasyDb.SetNotFoundAsError后，GetOne, GetOneData查询不到数据时返回
+var ErrNotFound = errors.New("未找到匹配的数据记录")
+
+// GetOneData 根据where条件查询单条数据，支持结构体指针、map或单列的值的指针接收结果。
+// 查询不到数据时，默认返回nil且dest保持不变；开启SetNotFoundAsError后返回ErrNotFound
 // querySQL SQL查询语句 例：select field1, field2 from table1 where name = $1 and status = $2
-// dest: 用于接收结果的结构体指针或map[string]any, *map[string]any
+// dest: 用于接收结果的结构体指针或map[string]any, *map[string]any。
+// 查询单列时，也可以是*int64, *string, *time.Time等单列的值的指针，支持的类型同GetMany的切片元素
 // args: SQL参数
 // 示例：
 //
 //	data := make(map[string]interface{}, 3)
-//	d.GetOneData("SELECT id, name, age, wallet_balance FROM $1", data, "users")
-//	// 传指针亦可 d.GetOneData("SELECT id, name, age, wallet_balance FROM $1", &data, "users")
+//	d.GetOneData("SELECT id, name, age, wallet_balance FROM users WHERE id = $1", data, 1)
+//	// 传指针亦可 d.GetOneData("SELECT id, name, age, wallet_balance FROM users WHERE id = $1", &data, 1)
 //	fmt.Printf("-----GetOneData--result(%+v)----\n", data)
 func (d *EasyDb) GetOneData(querySQL string, dest interface{}, args ...interface{}) error {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.GetOneDataContext(ctx, querySQL, dest, args...)
+}
+
+// GetOneDataContext 同GetOneData，使用ctx控制查询的取消和超时
+func (d *EasyDb) GetOneDataContext(ctx context.Context, querySQL string, dest interface{}, args ...interface{}) error {
+	return d.getOneData(ctx, d.db, querySQL, dest, args...)
+}
+
+// getOneData GetOneData的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) getOneData(ctx context.Context, conn sqlConn, querySQL string, dest interface{}, args ...interface{}) error {
+	querySQL, args, err := d.bindQuery(querySQL, args, 0)
+	if err != nil {
+		return err
+	}
+	return d.getOneDataBound(ctx, conn, querySQL, dest, args...)
+}
+
+// getOneDataBound 使用已处理好占位符的SQL语句执行查询
+func (d *EasyDb) getOneDataBound(ctx context.Context, conn sqlConn, querySQL string, dest interface{}, args ...interface{}) error {
+	if err := d.guardQuery(querySQL); err != nil {
+		return err
+	}
 	val := reflect.ValueOf(dest)
 	// if val.Kind() == reflect.Map {
 	// 	return fmt.Errorf("dest不能直接传map，要传有效的非空指针")
@@ -32,23 +63,26 @@ func (d *EasyDb) GetOneData(querySQL string, dest interface{}, args ...interface
 		return fmt.Errorf("dest必须是有效的非空指针")
 	}
 
-	stmt, err := d.db.Prepare(querySQL)
+	stmt, err := conn.PrepareContext(ctx, querySQL)
 	if err != nil {
-		return fmt.Errorf("预处理SQL语句失败: %v", err)
+		return fmt.Errorf("预处理SQL语句失败: %w", err)
 	}
 	defer stmt.Close()
 
 	// 改用Query获取sql.Rows（即使只查一行）
-	rows, err := stmt.Query(args...)
+	rows, err := stmt.QueryContext(ctx, d.driverArgs(args)...)
 	if err != nil {
-		return fmt.Errorf("查询失败: %v", err)
+		return fmt.Errorf("查询失败: %w", err)
 	}
 	defer rows.Close()
 
 	// 直接读取首行（模拟QueryRow行为）
 	if !rows.Next() {
 		if err := rows.Err(); err != nil {
-			return fmt.Errorf("查询错误: %v", err)
+			return fmt.Errorf("查询错误: %w", err)
+		}
+		if d.notFoundAsError {
+			return ErrNotFound
 		}
 		return nil // 无数据
 	}
@@ -59,6 +93,21 @@ func (d *EasyDb) GetOneData(querySQL string, dest interface{}, args ...interface
 	case map[string]any:
 		return d.scanRowToMap(rows, dd)
 	default:
+		if val.Kind() != reflect.Ptr {
+			return fmt.Errorf("不支持的dest类型(%T)", dest)
+		}
+		if elem := val.Elem(); d.isScalar(elem.Type()) {
+			// 单列的值，如*int64, *string, *time.Time
+			var nulls *nullScan
+			if !d.nullAsError {
+				nulls = newNullScan(1)
+			}
+			decs, err := d.typeDecoders(rows, []reflect.Type{elem.Type()})
+			if err != nil {
+				return err
+			}
+			return d.scanScalar(rows, elem, scanKindOf(elem.Type()), nulls, decs)
+		}
 		if val.Elem().Kind() == reflect.Struct {
 			return d.scanRowToStruct(rows, dest)
 		}
@@ -68,16 +117,18 @@ func (d *EasyDb) GetOneData(querySQL string, dest interface{}, args ...interface
 
 // DecodeInterface 用于解码GetOneData方法返回的结果
 // data 参数类型实际是 map[string][]byte
+// GetOneData, GetMany已按列的数据库类型解码map中的值，通常无需再调用此方法。此方法没有列类型信息，按int64, float64, bool的顺序尝试解析文本
 // 示例：
 //
 //	data := make(map[string]interface{}, 3)
-//	d.GetOneData("SELECT id, name, age, wallet_balance FROM $1", &data, "users")
+//	d.GetOneData("SELECT id, name, age, wallet_balance FROM users WHERE id = $1", &data, 1)
 //	fmt.Printf("-----GetOneData--result(%+v)----\n", d.DecodeInterface(data))
 func (d EasyDb) DecodeInterface(data map[string]interface{}) map[string]interface{} {
 	return decodeMapAny(data)
 }
 
-// GetOne 根据where条件查询单条数据
+// GetOne 根据where条件查询单条数据。
+// 查询不到数据时，默认返回nil且dest保持不变；开启SetNotFoundAsError后返回ErrNotFound
 // querySQL SQL查询语句
 // args: SQL参数
 // dest: 用于接收结果的结构体指针
@@ -87,21 +138,54 @@ func (d EasyDb) DecodeInterface(data map[string]interface{}) map[string]interfac
 //	var qrToUrl *string
 //	GetOne("select id, to_url from qr_list where code = $1", []interface{}{qrid, qrToUrl}, "codexxx")
 func (d *EasyDb) GetOne(querySQL string, dest []interface{}, args ...interface{}) error {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.GetOneContext(ctx, querySQL, dest, args...)
+}
+
+// GetOneContext 同GetOne，使用ctx控制查询的取消和超时
+func (d *EasyDb) GetOneContext(ctx context.Context, querySQL string, dest []interface{}, args ...interface{}) error {
+	return d.getOne(ctx, d.db, querySQL, dest, args...)
+}
+
+// getOne GetOne的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) getOne(ctx context.Context, conn sqlConn, querySQL string, dest []interface{}, args ...interface{}) error {
+	querySQL, args, err := d.bindQuery(querySQL, args, 0)
+	if err != nil {
+		return err
+	}
+	return d.getOneBound(ctx, conn, querySQL, dest, args...)
+}
+
+// getOneBound 使用已处理好占位符的SQL语句执行查询
+func (d *EasyDb) getOneBound(ctx context.Context, conn sqlConn, querySQL string, dest []interface{}, args ...interface{}) error {
+	if err := d.guardQuery(querySQL); err != nil {
+		return err
+	}
 	// 使用预处理语句执行查询，防止SQL注入
-	stmt, err := d.db.Prepare(querySQL)
+	stmt, err := conn.PrepareContext(ctx, querySQL)
 	if err != nil {
-		return fmt.Errorf("预处理SQL语句失败: %v", err)
+		return fmt.Errorf("预处理SQL语句失败: %w", err)
 	}
 	defer stmt.Close()
 
 	// 执行预处理查询
-	row := stmt.QueryRow(args...)
-	if err := row.Scan(dest...); err != nil {
+	row := stmt.QueryRowContext(ctx, d.driverArgs(args)...)
+	var nulls *nullScan
+	if !d.nullAsError {
+		nulls = newNullScan(len(dest))
+	}
+	if err := row.Scan(d.scanDest(dest, nulls)...); err != nil {
 		if err == sql.ErrNoRows {
-			// return fmt.Errorf("未找到匹配的数据记录")
+			if d.notFoundAsError {
+				return ErrNotFound
+			}
 			return nil
 		}
-		return fmt.Errorf("查询数据失败: %v", err)
+		return fmt.Errorf("查询数据失败: %w", err)
+	}
+	if nulls != nil {
+		nulls.finish()
 	}
 	return nil
 }
@@ -115,17 +199,41 @@ func (d *EasyDb) GetOne(querySQL string, dest []interface{}, args ...interface{}
 //	var datalist []map[string]interface{}
 //	d.GetMany("SELECT id, name, age, wallet_balance FROM users", &datalist)
 func (d *EasyDb) GetMany(querySQL string, dest interface{}, args ...interface{}) error {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.GetManyContext(ctx, querySQL, dest, args...)
+}
+
+// GetManyContext 同GetMany，使用ctx控制查询的取消和超时
+func (d *EasyDb) GetManyContext(ctx context.Context, querySQL string, dest interface{}, args ...interface{}) error {
+	return d.getMany(ctx, d.db, querySQL, dest, args...)
+}
+
+// getMany GetMany的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) getMany(ctx context.Context, conn sqlConn, querySQL string, dest interface{}, args ...interface{}) error {
+	querySQL, args, err := d.bindQuery(querySQL, args, 0)
+	if err != nil {
+		return err
+	}
+	return d.getManyBound(ctx, conn, querySQL, dest, args...)
+}
+
+// getManyBound 使用已处理好占位符的SQL语句执行查询
+func (d *EasyDb) getManyBound(ctx context.Context, conn sqlConn, querySQL string, dest interface{}, args ...interface{}) error {
+	if err := d.guardQuery(querySQL); err != nil {
+		return err
+	}
 	// 使用预处理语句执行查询，防止SQL注入
-	stmt, err := d.db.Prepare(querySQL)
+	stmt, err := conn.PrepareContext(ctx, querySQL)
 	if err != nil {
-		return fmt.Errorf("预处理SQL语句失败: %v", err)
+		return fmt.Errorf("预处理SQL语句失败: %w", err)
 	}
 	defer stmt.Close()
 
 	// 执行预处理查询
-	rows, err := stmt.Query(args...)
+	rows, err := stmt.QueryContext(ctx, d.driverArgs(args)...)
 	if err != nil {
-		return fmt.Errorf("查询数据失败: %v", err)
+		return fmt.Errorf("查询数据失败: %w", err)
 	}
 	defer rows.Close()
 
@@ -9,7 +9,12 @@ import (
 )
 
 // scanRows 扫描*sql.Rows数据到切片指针
-// dest 切片的指针
+// dest 切片的指针。切片元素支持：
+//
+//	map[string]interface{}, interface{}
+//	结构体和结构体指针，如[]User, []*User
+//	单列的值：bool, 各类int, uint, float, string及以其为底层类型的自定义类型，
+//	time.Time, []byte, json.RawMessage, 字节数组（如[16]byte的UUID），数组（如[]string, []int64），实现sql.Scanner或encoding.TextUnmarshaler的类型
 func (d *EasyDb) scanRows(rows *sql.Rows, dest interface{}) error {
 	v := reflect.ValueOf(dest)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
@@ -17,92 +22,60 @@ func (d *EasyDb) scanRows(rows *sql.Rows, dest interface{}) error {
 	}
 	sliceVal := v.Elem()
 	elemType := sliceVal.Type().Elem()
-	kidlist := []reflect.Kind{
-		reflect.Map,
-		reflect.Struct,
-		reflect.String,
-		reflect.Int,
-		reflect.Float64,
-		reflect.Interface,
+	// 结构体指针的切片，如[]*User
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr && elemType.Elem().Kind() == reflect.Struct && !isValueStruct(elemType.Elem()) {
+		structType = elemType.Elem()
 	}
-	for rows.Next() {
-		var elem reflect.Value
-		if !kindsContains(elemType.Kind(), kidlist) {
-			return fmt.Errorf("不支持的切片元素类型: %v", elemType.Kind())
-		}
-
-		if elemType.Kind() == reflect.String {
-			// 处理字符串
-			cols, err := rows.Columns()
-			if err != nil {
-				return err
-			}
-			if len(cols) < 1 {
-				return fmt.Errorf("没有可用的列")
-			}
-			var val any
-			if err := rows.Scan(&val); err != nil {
-				return err
-			}
-			var str string
-			switch v := val.(type) {
-			case []byte:
-				str = string(v)
-			case string:
-				str = v
-			default:
-				str = fmt.Sprintf("%v", v)
-			}
-			elem = reflect.ValueOf(str)
+	scalar := d.isScalar(elemType)
+	if !scalar && !kindsContains(structType.Kind(), []reflect.Kind{reflect.Map, reflect.Struct, reflect.Interface}) {
+		return fmt.Errorf("不支持的切片元素类型: %v", elemType)
+	}
+	kind := scanKindOf(elemType)
+	var ss *structScan
+	var nulls *nullScan
+	// 各列的解码函数，用于map和interface{}
+	var decs []columnDecode
+	// 单列的值的自定义解码函数
+	var typeDecs []decodeFunc
+	var err error
+	if scalar {
+		if !d.nullAsError {
+			nulls = newNullScan(1)
 		}
-
-		if elemType.Kind() == reflect.Int {
-			// 处理int
-			var val any
-			if err := rows.Scan(&val); err != nil {
-				return err
-			}
-			var intval int64
-			switch v := val.(type) {
-			case int64:
-				intval = v
-			case []byte:
-				intval, _ = strconv.ParseInt(string(v), 10, 64)
-			case string:
-				intval, _ = strconv.ParseInt(v, 10, 64)
-			default:
-				return fmt.Errorf("无法转换为int: %v", v)
-			}
-			elem = reflect.ValueOf(int(intval))
+		if typeDecs, err = d.typeDecoders(rows, []reflect.Type{elemType}); err != nil {
+			return err
 		}
-
-		if elemType.Kind() == reflect.Float64 {
-			// 处理float64
-			var val any
-			if err := rows.Scan(&val); err != nil {
+	}
+	for rows.Next() {
+		var elem reflect.Value
+		if scalar {
+			// 处理单列的值
+			elem = reflect.New(elemType).Elem()
+			if err := d.scanScalar(rows, elem, kind, nulls, typeDecs); err != nil {
 				return err
 			}
-			var floatval float64
-			switch v := val.(type) {
-			case float64:
-				floatval = v
-			case []byte:
-				floatval, _ = strconv.ParseFloat(string(v), 64)
-			case string:
-				floatval, _ = strconv.ParseFloat(v, 64)
-			default:
-				return fmt.Errorf("无法转换为float64: %v", v)
-			}
-			elem = reflect.ValueOf(floatval)
 		}
 
 		if elemType.Kind() == reflect.Interface {
 			// 处理interface{}
+			if decs == nil {
+				if decs, err = d.columnDecoders(rows); err != nil {
+					return err
+				}
+			}
 			var val interface{}
 			if err := rows.Scan(&val); err != nil {
 				return err
 			}
-			elem = reflect.ValueOf(decodeAny(val))
+			if err := decodeRow(decs, []interface{}{&val}); err != nil {
+				return err
+			}
+			if val == nil {
+				elem = reflect.Zero(elemType)
+			} else {
+				elem = reflect.ValueOf(val)
+			}
 		}
 
 		if elemType.Kind() == reflect.Map {
@@ -114,44 +87,44 @@ func (d *EasyDb) scanRows(rows *sql.Rows, dest interface{}) error {
 			for i := range values {
 				values[i] = new(interface{})
 			}
+			if decs == nil {
+				if decs, err = d.columnDecoders(rows); err != nil {
+					return err
+				}
+			}
 			if err := rows.Scan(values...); err != nil {
 				return err
 			}
+			if err := decodeRow(decs, values); err != nil {
+				return err
+			}
 			m := reflect.MakeMap(elemType)
 			for i, col := range cols {
-				vv := decodeAny(*(values[i].(*interface{})))
-				m.SetMapIndex(reflect.ValueOf(col), reflect.ValueOf(vv))
+				vv := reflect.Zero(elemType.Elem())
+				if val := *(values[i].(*interface{})); val != nil {
+					vv = reflect.ValueOf(val)
+				}
+				m.SetMapIndex(reflect.ValueOf(col), vv)
 			}
 			elem = m
 		}
 
-		if elemType.Kind() == reflect.Struct {
-			// 处理结构体
-			newElem := reflect.New(elemType).Interface()
-			cols, err := rows.Columns()
-			if err != nil {
-				return err
-			}
-			fields := make([]interface{}, len(cols))
-			destVal := reflect.ValueOf(newElem).Elem()
-			for i, col := range cols {
-				fieldFound := false
-				for j := 0; j < destVal.NumField(); j++ {
-					tag := destVal.Type().Field(j).Tag.Get("db")
-					if tag == col {
-						fields[i] = destVal.Field(j).Addr().Interface()
-						fieldFound = true
-						break
-					}
-				}
-				if !fieldFound {
-					return fmt.Errorf("列 %s 无对应的结构体字段", col)
+		if !scalar && structType.Kind() == reflect.Struct {
+			// 处理结构体。首行数据时获取列与结构体字段的对应关系，之后每行直接按序号取字段
+			if ss == nil {
+				if ss, err = d.newStructScan(rows, structType); err != nil {
+					return err
 				}
 			}
-			if err := rows.Scan(fields...); err != nil {
+			ptr := reflect.New(structType)
+			if err := ss.scan(rows, ptr.Elem()); err != nil {
 				return err
 			}
-			elem = destVal
+			if structType != elemType {
+				elem = ptr
+			} else {
+				elem = ptr.Elem()
+			}
 		}
 
 		sliceVal.Set(reflect.Append(sliceVal, elem))
@@ -175,7 +148,7 @@ func kindsContains(kind reflect.Kind, kinds []reflect.Kind) bool {
 func (d *EasyDb) scanRowToMap(rows *sql.Rows, dest map[string]any) error {
 	cols, err := rows.Columns()
 	if err != nil {
-		return fmt.Errorf("获取列失败: %v", err)
+		return fmt.Errorf("获取列失败: %w", err)
 	}
 
 	values := make([]interface{}, len(cols))
@@ -183,15 +156,21 @@ func (d *EasyDb) scanRowToMap(rows *sql.Rows, dest map[string]any) error {
 		values[i] = new(interface{})
 	}
 
+	decs, err := d.columnDecoders(rows)
+	if err != nil {
+		return err
+	}
 	if err := rows.Scan(values...); err != nil {
-		return fmt.Errorf("扫描失败: %v", err)
+		return fmt.Errorf("扫描失败: %w", err)
+	}
+	if err := decodeRow(decs, values); err != nil {
+		return err
 	}
 
 	// result := make(map[string]any)
 	for i, col := range cols {
-		dtval := *(values[i].(*interface{}))
 		// result[col] = decodeAny(dtval)
-		dest[col] = decodeAny(dtval)
+		dest[col] = *(values[i].(*interface{}))
 	}
 	// *dest = result
 	return nil
@@ -199,32 +178,13 @@ func (d *EasyDb) scanRowToMap(rows *sql.Rows, dest map[string]any) error {
 
 // scanRowToStruct 扫描*sql.Rows数据到结构体（通用逻辑）
 func (d *EasyDb) scanRowToStruct(rows *sql.Rows, dest interface{}) error {
+	destVal := reflect.ValueOf(dest).Elem()
 	// 使用rows.Columns()验证列与结构体标签匹配
-	cols, err := rows.Columns()
+	ss, err := d.newStructScan(rows, destVal.Type())
 	if err != nil {
-		return fmt.Errorf("获取列失败: %v", err)
-	}
-
-	destVal := reflect.ValueOf(dest).Elem()
-	fields := make([]interface{}, len(cols))
-
-	// 按列名映射结构体字段
-	for i, col := range cols {
-		fieldFound := false
-		for j := 0; j < destVal.NumField(); j++ {
-			tag := destVal.Type().Field(j).Tag.Get("db")
-			if tag == col {
-				fields[i] = destVal.Field(j).Addr().Interface()
-				fieldFound = true
-				break
-			}
-		}
-		if !fieldFound {
-			return fmt.Errorf("列 %s 无对应的结构体字段", col)
-		}
+		return err
 	}
-
-	return rows.Scan(fields...)
+	return ss.scan(rows, destVal)
 }
 
 func decodeMapAny(data map[string]any) map[string]any {
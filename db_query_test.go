@@ -0,0 +1,45 @@
+package easydb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNotFoundAsError(t *testing.T) {
+	result := &fakeResult{columns: []string{"id", "name"}}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+
+	var id int64
+	var name string
+	var user struct {
+		ID   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+	// 默认查询不到数据时返回nil
+	if err := d.GetOne("SELECT id, name FROM users WHERE id = $1", []interface{}{&id, &name}, 1); err != nil {
+		t.Errorf("GetOne got: %v", err)
+	}
+	if err := d.GetOneData("SELECT id, name FROM users WHERE id = $1", &user, 1); err != nil {
+		t.Errorf("GetOneData got: %v", err)
+	}
+
+	d.SetNotFoundAsError(true)
+	if err := d.GetOne("SELECT id, name FROM users WHERE id = $1", []interface{}{&id, &name}, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOne got: %v", err)
+	}
+	if err := d.GetOneData("SELECT id, name FROM users WHERE id = $1", &user, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetOneData got: %v", err)
+	}
+	if err := d.Select("id", "name").From("users").Where("id = ?", 1).GetOne([]interface{}{&id, &name}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SelectBuilder.GetOne got: %v", err)
+	}
+	if err := d.Select("id", "name").From("users").Where("id = ?", 1).GetOneData(&user); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SelectBuilder.GetOneData got: %v", err)
+	}
+	// GetMany查询不到数据时不返回错误
+	var users []map[string]interface{}
+	if err := d.GetMany("SELECT id, name FROM users", &users); err != nil || len(users) != 0 {
+		t.Errorf("GetMany got: %v %v", users, err)
+	}
+}
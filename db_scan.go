@@ -0,0 +1,254 @@
+package easydb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// scanKind 接收变量的扫描方式
+type scanKind uint8
+
+const (
+	// scanDirect 由database/sql直接扫描，包括NULL的处理。如指针、interface{}和实现sql.Scanner的类型
+	scanDirect scanKind = iota
+	// scanNotNull 由database/sql扫描，但不能接收NULL，如int, string，见nullScan
+	scanNotNull
+	// scanBytes 字节切片，如[]byte, json.RawMessage
+	scanBytes
+	// scanByteArray 字节数组，如[16]byte
+	scanByteArray
+	// scanText 实现encoding.TextUnmarshaler的类型
+	scanText
+	// scanTime time.Time，支持驱动以文本返回的时间，如未设置parseTime的mysql, sqlite的CURRENT_TIMESTAMP
+	scanTime
+	// scanJSON 带json选项的结构体字段，见jsonScanner
+	scanJSON
+	// scanArray 数组，如[]string, []int64，见arrayScanner
+	scanArray
+)
+
+// scanKindOf 获取类型t的接收变量的扫描方式
+func scanKindOf(t reflect.Type) scanKind {
+	pt := reflect.PointerTo(t)
+	switch {
+	case pt.Implements(scannerType):
+		return scanDirect
+	case t == timeType:
+		return scanTime
+	case pt.Implements(textUnmarshalerType):
+		// 优先于字节切片，如net.IP
+		return scanText
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return scanBytes
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8:
+		return scanByteArray
+	case isArrayType(t):
+		return scanArray
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return scanDirect
+	}
+	return scanNotNull
+}
+
+// isScalarType 判断类型t是否为单列的值：各基本类型及以其为底层类型的自定义类型，
+// time.Time, []byte, 字节数组，数组（如[]string, []int64），以及实现sql.Scanner或encoding.TextUnmarshaler的类型
+func isScalarType(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 || isArrayType(t) {
+			return true
+		}
+	}
+	pt := reflect.PointerTo(t)
+	return pt.Implements(scannerType) || pt.Implements(textUnmarshalerType)
+}
+
+// isScalar 判断类型t是否为单列的值，包括注册了转换函数的类型，见isScalarType
+func (d *EasyDb) isScalar(t reflect.Type) bool {
+	return isScalarType(t) || d.converters.byType[t] != nil
+}
+
+// scanTarget 获取第i列的扫描目标。v为可寻址的接收变量，kind为scanKindOf(v.Type())，decode为自定义解码函数，可为nil。
+// nulls为nil时，不能接收NULL的变量遇到NULL返回错误
+func scanTarget(v reflect.Value, kind scanKind, i int, nulls *nullScan, decode decodeFunc) interface{} {
+	if decode != nil {
+		return decodeScanner{v: v, decode: decode, nullAsError: nulls == nil}
+	}
+	switch kind {
+	case scanNotNull:
+		if nulls != nil {
+			return nulls.target(i, v)
+		}
+	case scanBytes:
+		return bytesScanner{v: v}
+	case scanByteArray:
+		return byteArrayScanner{v: v, nullAsError: nulls == nil}
+	case scanText:
+		return textScanner{v: v, nullAsError: nulls == nil}
+	case scanTime:
+		return timeScanner{v: v.Addr().Interface().(*time.Time), nullAsError: nulls == nil}
+	case scanJSON:
+		return jsonScanner{v: v}
+	case scanArray:
+		return arrayScanner{v: v}
+	}
+	return v.Addr().Interface()
+}
+
+// scanDest 将GetOne的接收变量替换为扫描目标。*sql.Row无法获取列类型，仅使用不限列类型的自定义解码函数
+func (d *EasyDb) scanDest(dest []interface{}, nulls *nullScan) []interface{} {
+	targets := make([]interface{}, len(dest))
+	for i, p := range dest {
+		v := reflect.ValueOf(p)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			targets[i] = p
+			continue
+		}
+		t := v.Elem().Type()
+		targets[i] = scanTarget(v.Elem(), scanKindOf(t), i, nulls, d.typeDecoder(t, ""))
+	}
+	return targets
+}
+
+// textValue 将数据库返回的值转换为文本
+func textValue(src interface{}) []byte {
+	switch s := src.(type) {
+	case []byte:
+		return s
+	case string:
+		return []byte(s)
+	case time.Time:
+		return []byte(s.Format(time.RFC3339Nano))
+	}
+	return []byte(fmt.Sprint(src))
+}
+
+// bytesScanner 扫描到字节切片，包括json.RawMessage等自定义类型。NULL为nil
+type bytesScanner struct {
+	v reflect.Value
+}
+
+func (s bytesScanner) Scan(src interface{}) error {
+	if src == nil {
+		s.v.SetZero()
+		return nil
+	}
+	s.v.SetBytes(bytes.Clone(textValue(src)))
+	return nil
+}
+
+// byteArrayScanner 扫描到字节数组，如[16]byte的UUID。
+// 数据长度与数组长度相同时直接复制；否则按十六进制文本解析，忽略其中的-，如 550e8400-e29b-41d4-a716-446655440000
+type byteArrayScanner struct {
+	v           reflect.Value
+	nullAsError bool
+}
+
+func (s byteArrayScanner) Scan(src interface{}) error {
+	if src == nil {
+		if s.nullAsError {
+			return fmt.Errorf("无法将NULL转换为%s", s.v.Type())
+		}
+		s.v.SetZero()
+		return nil
+	}
+	b := textValue(src)
+	if len(b) != s.v.Len() {
+		decoded, err := hex.DecodeString(strings.ReplaceAll(string(b), "-", ""))
+		if err != nil || len(decoded) != s.v.Len() {
+			return fmt.Errorf("无法将%q转换为%s", b, s.v.Type())
+		}
+		b = decoded
+	}
+	reflect.Copy(s.v, reflect.ValueOf(b))
+	return nil
+}
+
+// textScanner 扫描到实现encoding.TextUnmarshaler的类型
+type textScanner struct {
+	v           reflect.Value
+	nullAsError bool
+}
+
+func (s textScanner) Scan(src interface{}) error {
+	if src == nil {
+		if s.nullAsError {
+			return fmt.Errorf("无法将NULL转换为%s", s.v.Type())
+		}
+		s.v.SetZero()
+		return nil
+	}
+	return s.v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(textValue(src))
+}
+
+// timeLayouts 以文本返回的时间的格式
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// timeScanner 扫描到time.Time
+type timeScanner struct {
+	v           *time.Time
+	nullAsError bool
+}
+
+func (s timeScanner) Scan(src interface{}) error {
+	switch t := src.(type) {
+	case nil:
+		if s.nullAsError {
+			return fmt.Errorf("无法将NULL转换为time.Time")
+		}
+		*s.v = time.Time{}
+		return nil
+	case time.Time:
+		*s.v = t
+		return nil
+	case string, []byte:
+		text := string(textValue(t))
+		for _, layout := range timeLayouts {
+			if tm, err := time.Parse(layout, text); err == nil {
+				*s.v = tm
+				return nil
+			}
+		}
+		return fmt.Errorf("无法将%q转换为time.Time", text)
+	}
+	return fmt.Errorf("无法将%T转换为time.Time", src)
+}
+
+// scanScalar 扫描单列数据到可寻址的变量v。decs为typeDecoders的返回值，可为nil
+func (d *EasyDb) scanScalar(rows *sql.Rows, v reflect.Value, kind scanKind, nulls *nullScan, decs []decodeFunc) error {
+	var decode decodeFunc
+	if len(decs) > 0 {
+		decode = decs[0]
+	}
+	if err := rows.Scan(scanTarget(v, kind, 0, nulls, decode)); err != nil {
+		return err
+	}
+	if nulls != nil {
+		nulls.finish()
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package easydb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type scanStatus int8
+
+type scanLevel string
+
+func newScalarDb(tb testing.TB, values ...driver.Value) *EasyDb {
+	result := &fakeResult{columns: []string{"v"}}
+	for _, v := range values {
+		result.rows = append(result.rows, []driver.Value{v})
+	}
+	return newFakeDb(tb, result)
+}
+
+func TestScanScalarSlice(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	uuid := [16]byte{0x55, 0x0e, 0x84, 0x00, 0xe2, 0x9b, 0x41, 0xd4, 0xa7, 0x16, 0x44, 0x66, 0x55, 0x44, 0x00, 0x00}
+	cases := []struct {
+		values []driver.Value
+		dest   interface{}
+		want   interface{}
+	}{
+		{[]driver.Value{int64(1), "2", nil}, &[]int64{}, []int64{1, 2, 0}},
+		{[]driver.Value{int64(-3), []byte("4")}, &[]int8{}, []int8{-3, 4}},
+		{[]driver.Value{int64(5), "65535"}, &[]uint16{}, []uint16{5, 65535}},
+		{[]driver.Value{1.5, "2.5"}, &[]float32{}, []float32{1.5, 2.5}},
+		{[]driver.Value{true, int64(0), "1"}, &[]bool{}, []bool{true, false, true}},
+		{[]driver.Value{int64(1), "2"}, &[]scanStatus{}, []scanStatus{1, 2}},
+		{[]driver.Value{"info", []byte("warn")}, &[]scanLevel{}, []scanLevel{"info", "warn"}},
+		{[]driver.Value{"12.30", []byte("0.10")}, &[]string{}, []string{"12.30", "0.10"}},
+		{[]driver.Value{now, nil, "2024-01-02 03:04:05", []byte("2024-01-02T03:04:05Z")}, &[]time.Time{}, []time.Time{now, {}, now, now}},
+		{[]driver.Value{[]byte("ab"), nil}, &[][]byte{}, [][]byte{[]byte("ab"), nil}},
+		{[]driver.Value{`{"a":1}`, []byte(`[1]`)}, &[]json.RawMessage{}, []json.RawMessage{json.RawMessage(`{"a":1}`), json.RawMessage(`[1]`)}},
+		{[]driver.Value{uuid[:], "550e8400-e29b-41d4-a716-446655440000"}, &[][16]byte{}, [][16]byte{uuid, uuid}},
+		{[]driver.Value{"127.0.0.1", nil}, &[]net.IP{}, []net.IP{net.ParseIP("127.0.0.1"), nil}},
+		{[]driver.Value{"a", nil}, &[]sql.NullString{}, []sql.NullString{{String: "a", Valid: true}, {}}},
+	}
+	for _, c := range cases {
+		d := newScalarDb(t, c.values...)
+		if err := d.GetMany("SELECT v", c.dest); err != nil {
+			t.Errorf("GetMany(%T) error: %v", c.dest, err)
+			continue
+		}
+		if got := reflect.ValueOf(c.dest).Elem().Interface(); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("GetMany(%T) got: %v, want: %v", c.dest, got, c.want)
+		}
+	}
+
+	d := newScalarDb(t, "x")
+	var ints []int
+	if err := d.GetMany("SELECT v", &ints); err == nil {
+		t.Error("GetMany should fail to convert x to int")
+	}
+	var chans []chan int
+	if err := d.GetMany("SELECT v", &chans); err == nil {
+		t.Error("GetMany should fail on unsupported slice element")
+	}
+}
+
+func TestGetOneDataScalar(t *testing.T) {
+	d := newScalarDb(t, int64(42))
+	var n int64
+	if err := d.GetOneData("SELECT COUNT(*) FROM users", &n); err != nil || n != 42 {
+		t.Errorf("GetOneData got: %d %v", n, err)
+	}
+	var s string
+	if err := d.GetOneData("SELECT COUNT(*) FROM users", &s); err != nil || s != "42" {
+		t.Errorf("GetOneData got: %q %v", s, err)
+	}
+	var ns sql.NullInt64
+	if err := d.GetOneData("SELECT COUNT(*) FROM users", &ns); err != nil || ns.Int64 != 42 {
+		t.Errorf("GetOneData got: %v %v", ns, err)
+	}
+
+	d = newScalarDb(t, "192.168.0.1")
+	var ip net.IP
+	if err := d.GetOneData("SELECT ip FROM users", &ip); err != nil || !ip.Equal(net.ParseIP("192.168.0.1")) {
+		t.Errorf("GetOneData got: %v %v", ip, err)
+	}
+	var levels scanLevel
+	if err := d.GetOne("SELECT ip FROM users", []interface{}{&levels}); err != nil || levels != "192.168.0.1" {
+		t.Errorf("GetOne got: %v %v", levels, err)
+	}
+}
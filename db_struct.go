@@ -0,0 +1,433 @@
+package easydb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// fieldTag 结构体字段的db标签。
+// 格式为 db:"列名,选项1,选项2"，列名为-时忽略该字段。可用选项：
+//
+//	pk: 主键。插入时为零值则由数据库生成，并回写到结构体；更新时作为WHERE条件
+//	readonly: 只读，插入和更新时忽略，如数据库自动维护的created_at
+//	omitempty: 零值时，插入和更新时忽略
+//	json: 以JSON格式存储，写入时序列化为JSON文本，读取时反序列化。字段可以是结构体、map或切片
+type fieldTag struct {
+	name      string
+	pk        bool
+	readonly  bool
+	omitempty bool
+	json      bool
+}
+
+// parseTag 解析db标签
+func parseTag(tag string) fieldTag {
+	name, opts, _ := strings.Cut(tag, ",")
+	ft := fieldTag{name: strings.TrimSpace(name)}
+	for _, opt := range strings.Split(opts, ",") {
+		switch strings.TrimSpace(opt) {
+		case "pk":
+			ft.pk = true
+		case "readonly":
+			ft.readonly = true
+		case "omitempty":
+			ft.omitempty = true
+		case "json":
+			ft.json = true
+		}
+	}
+	return ft
+}
+
+// structField 带db标签的结构体字段
+type structField struct {
+	// index 字段的索引路径。嵌入的结构体中的字段，路径长度大于1
+	index []int
+	typ   reflect.Type
+	tag   fieldTag
+}
+
+// value 获取结构体v中的字段值。路径上嵌入的结构体指针为nil时，返回零值
+func (f structField) value(v reflect.Value) reflect.Value {
+	fv, err := v.FieldByIndexErr(f.index)
+	if err != nil {
+		return reflect.Zero(f.typ)
+	}
+	return fv
+}
+
+// arg 获取结构体v中的字段值，用作写入数据库的参数。带json选项的字段序列化为JSON文本，为nil时写入NULL
+func (f structField) arg(v reflect.Value) interface{} {
+	fv := f.value(v)
+	if !f.tag.json {
+		return fv.Interface()
+	}
+	switch fv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if fv.IsNil() {
+			return nil
+		}
+	}
+	return jsonArg{v: fv.Interface()}
+}
+
+// fieldByIndexAlloc 按索引路径获取字段，路径上的结构体指针为nil时，分配新的结构体
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// isValueStruct 判断结构体类型是否作为单列的值，如time.Time, sql.NullString，而不是展开为多列
+func isValueStruct(t reflect.Type) bool {
+	return t == timeType || t.Implements(valuerType) || reflect.PointerTo(t).Implements(scannerType)
+}
+
+// walkStructFields 遍历结构体中带db标签的字段。
+// 嵌入的结构体（不带db标签），其字段视为外层结构体的字段。
+// 带db标签（不带json选项）的结构体字段为嵌套的结构体，nested为true时以 标签名.列名 和 标签名_列名 遍历其字段，否则忽略。
+// fieldName不为nil时，不带db标签的导出字段，以fieldName(字段名)作为列名，返回空字符串时忽略该字段。
+// fn的参数names为字段对应的列名。
+func walkStructFields(t reflect.Type, nested bool, fieldName func(string) string, fn func(names []string, f structField)) {
+	walkFields(t, []string{""}, nil, map[reflect.Type]bool{t: true}, nested, fieldName, fn)
+}
+
+func walkFields(t reflect.Type, prefixes []string, parent []int, visiting map[reflect.Type]bool, nested bool, fieldName func(string) string, fn func(names []string, f structField)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := parseTag(f.Tag.Get("db"))
+		if tag.name == "-" {
+			continue
+		}
+		index := append(append(make([]int, 0, len(parent)+1), parent...), i)
+		ft := f.Type
+		isPtr := ft.Kind() == reflect.Ptr
+		if isPtr {
+			ft = ft.Elem()
+		}
+		isStruct := ft.Kind() == reflect.Struct && !isValueStruct(ft) && !visiting[ft]
+		if f.Anonymous && tag.name == "" && isStruct {
+			// 未导出类型的嵌入结构体指针无法分配，忽略
+			if isPtr && !f.IsExported() {
+				continue
+			}
+			visiting[ft] = true
+			walkFields(ft, prefixes, index, visiting, nested, fieldName, fn)
+			delete(visiting, ft)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if tag.name == "" && fieldName != nil {
+			tag.name = fieldName(f.Name)
+		}
+		if tag.name == "" {
+			continue
+		}
+		names := make([]string, len(prefixes))
+		for j, prefix := range prefixes {
+			names[j] = prefix + tag.name
+		}
+		if isStruct && !tag.json {
+			if nested {
+				next := make([]string, 0, len(names)*2)
+				for _, name := range names {
+					next = append(next, name+".", name+"_")
+				}
+				visiting[ft] = true
+				walkFields(ft, next, index, visiting, nested, fieldName, fn)
+				delete(visiting, ft)
+			}
+			continue
+		}
+		fn(names, structField{index: index, typ: f.Type, tag: tag})
+	}
+}
+
+// getStructFields 获取结构体中带db标签的导出字段，包括嵌入的结构体中的字段。
+// 同名的列，外层结构体的字段优先。
+func getStructFields(t reflect.Type) []structField {
+	var all []structField
+	depths := make(map[string]int)
+	walkStructFields(t, false, nil, func(names []string, f structField) {
+		if depth, ok := depths[f.tag.name]; !ok || len(f.index) < depth {
+			depths[f.tag.name] = len(f.index)
+		}
+		all = append(all, f)
+	})
+	var fields []structField
+	added := make(map[string]bool, len(depths))
+	for _, f := range all {
+		if len(f.index) == depths[f.tag.name] && !added[f.tag.name] {
+			added[f.tag.name] = true
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// structColumns 获取结构体中列名与字段的对应关系，包括嵌入的结构体和嵌套的结构体中的字段。
+// 同名的列，外层结构体的字段优先。fieldName见walkStructFields。
+func structColumns(t reflect.Type, fieldName func(string) string) map[string]structField {
+	columns := make(map[string]structField)
+	walkStructFields(t, true, fieldName, func(names []string, f structField) {
+		for _, name := range names {
+			if old, ok := columns[name]; ok && len(old.index) <= len(f.index) {
+				continue
+			}
+			columns[name] = f
+		}
+	})
+	return columns
+}
+
+// structValue 获取结构体指针指向的结构体
+func structValue(src interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("参数必须是结构体指针，不支持%T", src)
+	}
+	return v.Elem(), nil
+}
+
+// structWriteColumns 获取插入或更新时写入的列和值。忽略readonly字段和omitempty的零值字段，skipPk为true时忽略主键
+func structWriteColumns(v reflect.Value, fields []structField, skipPk bool) ([]string, []interface{}) {
+	var columns []string
+	var values []interface{}
+	for _, f := range fields {
+		fv := f.value(v)
+		if f.tag.readonly || (skipPk && f.tag.pk) || (f.tag.omitempty && fv.IsZero()) {
+			continue
+		}
+		columns = append(columns, f.tag.name)
+		values = append(values, f.arg(v))
+	}
+	return columns, values
+}
+
+// structPks 获取主键字段
+func structPks(fields []structField) []structField {
+	var pks []structField
+	for _, f := range fields {
+		if f.tag.pk {
+			pks = append(pks, f)
+		}
+	}
+	return pks
+}
+
+// InsertStruct 根据结构体的db标签插入一行数据。src为结构体指针。
+// 主键字段(db:"id,pk")为零值时，由数据库生成，插入后回写到结构体。
+// postgres, sqlite3, sqlserver通过RETURNING/OUTPUT子句获取主键，其他数据库通过LastInsertId获取。
+// 示例：
+//
+//	type User struct {
+//		ID        int       `db:"id,pk"`
+//		Name      string    `db:"name"`
+//		Age       int       `db:"age,omitempty"`
+//		CreatedAt time.Time `db:"created_at,readonly"`
+//		Temp      string    `db:"-"`
+//	}
+//	user := User{Name: "Tom", Age: 18}
+//	err := d.InsertStruct("users", &user)
+//	fmt.Println(user.ID)
+func (d *EasyDb) InsertStruct(table string, src interface{}) error {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.InsertStructContext(ctx, table, src)
+}
+
+// InsertStructContext 同InsertStruct，使用ctx控制执行的取消和超时
+func (d *EasyDb) InsertStructContext(ctx context.Context, table string, src interface{}) error {
+	return d.insertStruct(ctx, d.db, table, src)
+}
+
+// insertStruct InsertStruct的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) insertStruct(ctx context.Context, conn sqlConn, table string, src interface{}) error {
+	v, err := structValue(src)
+	if err != nil {
+		return err
+	}
+	fields := getStructFields(v.Type())
+	pks := structPks(fields)
+	// 单一主键为零值时，由数据库生成
+	autoPk := len(pks) == 1 && pks[0].value(v).IsZero()
+	columns, values := structWriteColumns(v, fields, autoPk)
+	if len(columns) == 0 {
+		return fmt.Errorf("结构体%s没有可插入的字段", v.Type())
+	}
+	b := &InsertBuilder{writeBuilder: writeBuilder{db: d, conn: conn, table: table}, columns: columns, rows: [][]interface{}{values}}
+	if !autoPk {
+		_, err = b.ExecContext(ctx)
+		return err
+	}
+
+	pkField := fieldByIndexAlloc(v, pks[0].index)
+	if _, _, rerr := returningClause(d.dialect, []string{pks[0].tag.name}, "INSERTED"); rerr == nil {
+		b.Returning(pks[0].tag.name)
+		query, args, err := b.ToSQL()
+		if err != nil {
+			return err
+		}
+		return d.getOneBound(ctx, conn, query, []interface{}{pkField.Addr().Interface()}, args...)
+	}
+
+	result, err := b.ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("获取生成的主键失败: %w", err)
+	}
+	switch pkField.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		pkField.SetInt(id)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		pkField.SetUint(uint64(id))
+	default:
+		return fmt.Errorf("主键字段类型%s无法接收LastInsertId", pkField.Type())
+	}
+	return nil
+}
+
+// UpdateStruct 根据结构体的db标签，按主键更新一行数据，返回受影响的行数。src为结构体指针。
+// 以pk字段作为WHERE条件，更新其他字段。忽略readonly字段和omitempty的零值字段。
+// 示例：
+//
+//	user.Age = 19
+//	affected, err := d.UpdateStruct("users", &user)
+func (d *EasyDb) UpdateStruct(table string, src interface{}) (int64, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.UpdateStructContext(ctx, table, src)
+}
+
+// UpdateStructContext 同UpdateStruct，使用ctx控制执行的取消和超时
+func (d *EasyDb) UpdateStructContext(ctx context.Context, table string, src interface{}) (int64, error) {
+	return d.updateStruct(ctx, d.db, table, src)
+}
+
+// updateStruct UpdateStruct的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) updateStruct(ctx context.Context, conn sqlConn, table string, src interface{}) (int64, error) {
+	v, err := structValue(src)
+	if err != nil {
+		return 0, err
+	}
+	fields := getStructFields(v.Type())
+	pks := structPks(fields)
+	if len(pks) == 0 {
+		return 0, fmt.Errorf("结构体%s没有主键字段，请使用db:\"列名,pk\"标签设置主键", v.Type())
+	}
+	columns, values := structWriteColumns(v, fields, true)
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("结构体%s没有可更新的字段", v.Type())
+	}
+	b := &UpdateBuilder{writeBuilder: writeBuilder{db: d, conn: conn, table: table}}
+	for i, col := range columns {
+		b.Set(col, values[i])
+	}
+	for _, pk := range pks {
+		b.Where(pk.tag.name+" = ?", pk.value(v).Interface())
+	}
+	result, err := b.ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SaveStruct 保存结构体。主键为零值时插入数据（见InsertStruct），否则按主键更新数据（见UpdateStruct）。
+// 按主键更新时没有匹配的记录（如由客户端生成的UUID主键），则插入数据。
+// mysql中数据未改变时受影响的行数也为0，此时插入返回的主键冲突错误将被忽略。
+func (d *EasyDb) SaveStruct(table string, src interface{}) error {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.SaveStructContext(ctx, table, src)
+}
+
+// SaveStructContext 同SaveStruct，使用ctx控制执行的取消和超时
+func (d *EasyDb) SaveStructContext(ctx context.Context, table string, src interface{}) error {
+	return d.saveStruct(ctx, d.db, table, src)
+}
+
+// saveStruct SaveStruct的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) saveStruct(ctx context.Context, conn sqlConn, table string, src interface{}) error {
+	v, err := structValue(src)
+	if err != nil {
+		return err
+	}
+	pks := structPks(getStructFields(v.Type()))
+	if len(pks) == 0 {
+		return fmt.Errorf("结构体%s没有主键字段，请使用db:\"列名,pk\"标签设置主键", v.Type())
+	}
+	for _, pk := range pks {
+		if pk.value(v).IsZero() {
+			return d.insertStruct(ctx, conn, table, src)
+		}
+	}
+	n, err := d.updateStruct(ctx, conn, table, src)
+	if err != nil || n > 0 {
+		return err
+	}
+	// 没有匹配主键的记录时插入数据
+	if err = d.insertStruct(ctx, conn, table, src); err != nil && !IsUniqueViolation(err) {
+		return err
+	}
+	return nil
+}
+
+// InsertStruct 在事务中根据结构体的db标签插入一行数据。用法同EasyDb.InsertStruct
+func (t *EasyTx) InsertStruct(table string, src interface{}) error {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.InsertStructContext(ctx, table, src)
+}
+
+// InsertStructContext 同InsertStruct，使用ctx控制执行的取消和超时
+func (t *EasyTx) InsertStructContext(ctx context.Context, table string, src interface{}) error {
+	return t.db.insertStruct(ctx, t.tx, table, src)
+}
+
+// UpdateStruct 在事务中根据结构体的db标签，按主键更新一行数据。用法同EasyDb.UpdateStruct
+func (t *EasyTx) UpdateStruct(table string, src interface{}) (int64, error) {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.UpdateStructContext(ctx, table, src)
+}
+
+// UpdateStructContext 同UpdateStruct，使用ctx控制执行的取消和超时
+func (t *EasyTx) UpdateStructContext(ctx context.Context, table string, src interface{}) (int64, error) {
+	return t.db.updateStruct(ctx, t.tx, table, src)
+}
+
+// SaveStruct 在事务中保存结构体。用法同EasyDb.SaveStruct
+func (t *EasyTx) SaveStruct(table string, src interface{}) error {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.SaveStructContext(ctx, table, src)
+}
+
+// SaveStructContext 同SaveStruct，使用ctx控制执行的取消和超时
+func (t *EasyTx) SaveStructContext(ctx context.Context, table string, src interface{}) error {
+	return t.db.saveStruct(ctx, t.tx, table, src)
+}
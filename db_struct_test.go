@@ -0,0 +1,103 @@
+package easydb
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type structTagUser struct {
+	ID      int    `db:"id,pk"`
+	Name    string `db:"name"`
+	Age     int    `db:"age,omitempty"`
+	Created string `db:"created_at,readonly"`
+	Temp    string `db:"-"`
+	NoTag   string
+}
+
+func TestParseTag(t *testing.T) {
+	tag := parseTag("id, pk,readonly")
+	if tag.name != "id" || !tag.pk || !tag.readonly || tag.omitempty {
+		t.Errorf("parseTag got: %+v", tag)
+	}
+	if tag = parseTag("name"); tag.name != "name" || tag.pk || tag.json {
+		t.Errorf("parseTag got: %+v", tag)
+	}
+	if tag = parseTag("meta,json,omitempty"); tag.name != "meta" || !tag.json || !tag.omitempty {
+		t.Errorf("parseTag got: %+v", tag)
+	}
+}
+
+func TestStructWriteColumns(t *testing.T) {
+	u := structTagUser{Name: "Tom", Created: "2024-01-01", Temp: "x", NoTag: "y"}
+	v := reflect.ValueOf(u)
+	fields := getStructFields(v.Type())
+	if len(fields) != 4 || len(structPks(fields)) != 1 {
+		t.Fatalf("getStructFields got: %+v", fields)
+	}
+
+	columns, values := structWriteColumns(v, fields, true)
+	if !reflect.DeepEqual(columns, []string{"name"}) || !reflect.DeepEqual(values, []interface{}{"Tom"}) {
+		t.Errorf("structWriteColumns got: %v %v", columns, values)
+	}
+	u.ID, u.Age = 3, 18
+	columns, values = structWriteColumns(reflect.ValueOf(u), fields, false)
+	if !reflect.DeepEqual(columns, []string{"id", "name", "age"}) || !reflect.DeepEqual(values, []interface{}{3, "Tom", 18}) {
+		t.Errorf("structWriteColumns got: %v %v", columns, values)
+	}
+}
+
+func TestSaveStruct(t *testing.T) {
+	type uuidUser struct {
+		ID   string `db:"id,pk"`
+		Name string `db:"name"`
+	}
+	var affected int64
+	var insertErr error
+	result := &fakeResult{
+		rowsAffected: func(query string) int64 {
+			if strings.HasPrefix(query, "UPDATE") {
+				return affected
+			}
+			return 1
+		},
+		err: func(query string) error {
+			if strings.HasPrefix(query, "INSERT") {
+				return insertErr
+			}
+			return nil
+		},
+	}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+	update := "UPDATE users SET name = $1 WHERE id = $2"
+	insert := "INSERT INTO users (id, name) VALUES ($1, $2)"
+
+	// 客户端生成的主键没有匹配的记录时，插入数据
+	if err := d.SaveStruct("users", &uuidUser{ID: "uuid-1", Name: "Tom"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := result.executed(); !reflect.DeepEqual(got, []string{update, insert}) {
+		t.Errorf("SaveStruct insert got: %v", got)
+	}
+
+	affected = 1
+	if err := d.SaveStruct("users", &uuidUser{ID: "uuid-1", Name: "Tom"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := result.executed(); !reflect.DeepEqual(got, []string{update}) {
+		t.Errorf("SaveStruct update got: %v", got)
+	}
+
+	// mysql中数据未改变时受影响的行数为0，插入时主键冲突，视为已保存
+	affected = 0
+	insertErr = &DbError{Driver: "mysql", Code: "1062", Kind: ErrorKindUniqueViolation, Err: errors.New("duplicate entry")}
+	if err := d.SaveStruct("users", &uuidUser{ID: "uuid-1", Name: "Tom"}); err != nil {
+		t.Errorf("SaveStruct unchanged got: %v", err)
+	}
+	insertErr = errors.New("insert failed")
+	if err := d.SaveStruct("users", &uuidUser{ID: "uuid-1", Name: "Tom"}); !errors.Is(err, insertErr) {
+		t.Errorf("SaveStruct insert error got: %v", err)
+	}
+}
@@ -0,0 +1,351 @@
+package easydb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// EasyTx 数据库事务。提供与EasyDb相同的查询、扫描方法，所有操作都在同一个事务中执行。
+// 使用EasyDb.Begin或EasyDb.BeginTx方法创建，最后必须调用Commit或Rollback结束事务。
+// 示例：
+//
+//	tx, err := d.Begin()
+//	if err != nil {
+//		return err
+//	}
+//	defer tx.Rollback() // Commit之后再Rollback不会有任何影响
+//	err = tx.ExecInsert("users", []string{"name", "age"}, []interface{}{"Tom", 18})
+//	if err != nil {
+//		return err
+//	}
+//	var users []User
+//	err = tx.GetMany("SELECT id, name, age, wallet_balance FROM users", &users)
+//	if err != nil {
+//		return err
+//	}
+//	return tx.Commit()
+//
+// 在EasyTx上再次调用Begin，将创建保存点(SAVEPOINT)作为嵌套事务。嵌套事务回滚时，只回滚到保存点，不影响外层事务。
+type EasyTx struct {
+	db *EasyDb
+	tx *sql.Tx
+	// 嵌套事务的保存点名称。最外层事务为空
+	savepoint string
+	// 保存点序号，同一事务的所有嵌套事务共用
+	seq *int
+	// 嵌套事务是否已结束
+	done bool
+}
+
+// Begin 开始一个事务
+func (d *EasyDb) Begin() (*EasyTx, error) {
+	return d.BeginTx(context.Background(), nil)
+}
+
+// BeginTx 开始一个事务。ctx在事务结束前被取消时，事务将被回滚。
+// opts 可设置事务的隔离级别和只读属性，为nil时使用数据库驱动的默认值。
+func (d *EasyDb) BeginTx(ctx context.Context, opts *sql.TxOptions) (*EasyTx, error) {
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("开始事务失败: %w", err)
+	}
+	return &EasyTx{db: d, tx: tx, seq: new(int)}, nil
+}
+
+// GetSqlTx 获取*sql.Tx实例
+func (t *EasyTx) GetSqlTx() *sql.Tx {
+	return t.tx
+}
+
+// Begin 创建嵌套事务。在当前事务中设置保存点(SAVEPOINT)。
+// 嵌套事务Commit时释放保存点，Rollback时回滚到保存点，外层事务可继续执行。
+// 嵌套事务的修改，在最外层事务提交后才真正生效。
+func (t *EasyTx) Begin() (*EasyTx, error) {
+	return t.BeginContext(context.Background())
+}
+
+// BeginContext 同Begin，使用ctx控制执行的取消和超时
+func (t *EasyTx) BeginContext(ctx context.Context) (*EasyTx, error) {
+	if t.done {
+		return nil, sql.ErrTxDone
+	}
+	*t.seq++
+	name := fmt.Sprintf("easydb_sp_%d", *t.seq)
+	if _, err := t.tx.ExecContext(ctx, t.db.dialect.Savepoint(name)); err != nil {
+		return nil, fmt.Errorf("创建保存点失败: %w", err)
+	}
+	return &EasyTx{db: t.db, tx: t.tx, savepoint: name, seq: t.seq}, nil
+}
+
+// IsNested 是否为嵌套事务
+func (t *EasyTx) IsNested() bool {
+	return t.savepoint != ""
+}
+
+// Commit 提交事务。嵌套事务则释放保存点。
+func (t *EasyTx) Commit() error {
+	if t.savepoint == "" {
+		return t.tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	query := t.db.dialect.ReleaseSavepoint(t.savepoint)
+	if query == "" {
+		return nil
+	}
+	if _, err := t.tx.Exec(query); err != nil {
+		return fmt.Errorf("释放保存点失败: %w", err)
+	}
+	return nil
+}
+
+// Rollback 回滚事务。嵌套事务则回滚到保存点。
+func (t *EasyTx) Rollback() error {
+	if t.savepoint == "" {
+		return t.tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	if _, err := t.tx.Exec(t.db.dialect.RollbackToSavepoint(t.savepoint)); err != nil {
+		return fmt.Errorf("回滚到保存点失败: %w", err)
+	}
+	// 回滚到保存点后，保存点依然存在，需要释放
+	query := t.db.dialect.ReleaseSavepoint(t.savepoint)
+	if query == "" {
+		return nil
+	}
+	if _, err := t.tx.Exec(query); err != nil {
+		return fmt.Errorf("释放保存点失败: %w", err)
+	}
+	return nil
+}
+
+// WithTx 在嵌套事务中执行fn。fn返回nil则释放保存点，返回错误或发生panic则回滚到保存点。
+// 嵌套事务失败时，外层事务可继续执行。
+// 示例：
+//
+//	err := d.WithTx(ctx, nil, func(tx *easydb.EasyTx) error {
+//		// ...
+//		err := tx.WithTx(ctx, func(inner *easydb.EasyTx) error {
+//			_, err := inner.ExecContext(ctx, "INSERT INTO logs (msg) VALUES ($1)", "hello")
+//			return err
+//		})
+//		if err != nil {
+//			log.Println("写入日志失败，仅回滚日志部分", err)
+//		}
+//		return nil
+//	})
+func (t *EasyTx) WithTx(ctx context.Context, fn func(tx *EasyTx) error) error {
+	inner, err := t.BeginContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			inner.Rollback()
+			panic(p) // 重新抛出panic
+		}
+	}()
+	if err = fn(inner); err != nil {
+		if rberr := inner.Rollback(); rberr != nil && !errors.Is(rberr, sql.ErrTxDone) {
+			return errors.Join(err, rberr)
+		}
+		return err
+	}
+	return inner.Commit()
+}
+
+// Query 在事务中执行查询
+func (t *EasyTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.QueryContext(t.db.newQueryContext(), query, args...)
+}
+
+// QueryContext 同Query，使用ctx控制查询的取消和超时
+func (t *EasyTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.db.query(ctx, t.tx, query, args...)
+}
+
+// QueryRow 在事务中查询单行
+func (t *EasyTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.QueryRowContext(t.db.newQueryContext(), query, args...)
+}
+
+// QueryRowContext 同QueryRow，使用ctx控制查询的取消和超时
+func (t *EasyTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.db.queryRow(ctx, t.tx, query, args...)
+}
+
+// Exec 在事务中执行SQL语句
+func (t *EasyTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.ExecContext(ctx, query, args...)
+}
+
+// ExecContext 同Exec，使用ctx控制执行的取消和超时
+func (t *EasyTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.db.exec(ctx, t.tx, query, args...)
+}
+
+// GetOne 在事务中查询单条数据。用法同EasyDb.GetOne
+func (t *EasyTx) GetOne(querySQL string, dest []interface{}, args ...interface{}) error {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.GetOneContext(ctx, querySQL, dest, args...)
+}
+
+// GetOneContext 同GetOne，使用ctx控制查询的取消和超时
+func (t *EasyTx) GetOneContext(ctx context.Context, querySQL string, dest []interface{}, args ...interface{}) error {
+	return t.db.getOne(ctx, t.tx, querySQL, dest, args...)
+}
+
+// GetOneData 在事务中查询单条数据，支持结构体指针或map接收结果。用法同EasyDb.GetOneData
+func (t *EasyTx) GetOneData(querySQL string, dest interface{}, args ...interface{}) error {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.GetOneDataContext(ctx, querySQL, dest, args...)
+}
+
+// GetOneDataContext 同GetOneData，使用ctx控制查询的取消和超时
+func (t *EasyTx) GetOneDataContext(ctx context.Context, querySQL string, dest interface{}, args ...interface{}) error {
+	return t.db.getOneData(ctx, t.tx, querySQL, dest, args...)
+}
+
+// GetMany 在事务中查询多条数据。用法同EasyDb.GetMany
+func (t *EasyTx) GetMany(querySQL string, dest interface{}, args ...interface{}) error {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.GetManyContext(ctx, querySQL, dest, args...)
+}
+
+// GetManyContext 同GetMany，使用ctx控制查询的取消和超时
+func (t *EasyTx) GetManyContext(ctx context.Context, querySQL string, dest interface{}, args ...interface{}) error {
+	return t.db.getMany(ctx, t.tx, querySQL, dest, args...)
+}
+
+// ExecInsert 在事务中执行单条插入语句。用法同EasyDb.ExecInsert
+func (t *EasyTx) ExecInsert(tableName string, columns []string, values []interface{}) error {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.ExecInsertContext(ctx, tableName, columns, values)
+}
+
+// ExecInsertContext 同ExecInsert，使用ctx控制执行的取消和超时
+func (t *EasyTx) ExecInsertContext(ctx context.Context, tableName string, columns []string, values []interface{}) error {
+	return t.db.execInsert(ctx, t.tx, tableName, columns, values)
+}
+
+// ExecUpdateByValues 在事务中执行更新语句。用法同EasyDb.ExecUpdateByValues
+func (t *EasyTx) ExecUpdateByValues(tableName string, columns []string, values []interface{}, whereClause string, whereValues []interface{}) error {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.ExecUpdateByValuesContext(ctx, tableName, columns, values, whereClause, whereValues)
+}
+
+// ExecUpdateByValuesContext 同ExecUpdateByValues，使用ctx控制执行的取消和超时
+func (t *EasyTx) ExecUpdateByValuesContext(ctx context.Context, tableName string, columns []string, values []interface{}, whereClause string, whereValues []interface{}) error {
+	return t.db.execUpdateByValues(ctx, t.tx, tableName, columns, values, whereClause, whereValues)
+}
+
+// ExecDelete 在事务中执行删除语句。用法同EasyDb.ExecDelete
+func (t *EasyTx) ExecDelete(tableName string, whereClause string, whereValues []interface{}) (int64, error) {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.ExecDeleteContext(ctx, tableName, whereClause, whereValues)
+}
+
+// ExecDeleteContext 同ExecDelete，使用ctx控制执行的取消和超时
+func (t *EasyTx) ExecDeleteContext(ctx context.Context, tableName string, whereClause string, whereValues []interface{}) (int64, error) {
+	return t.db.execDelete(ctx, t.tx, tableName, whereClause, whereValues)
+}
+
+// TxOptions WithTx方法的事务选项
+type TxOptions struct {
+	// 事务的隔离级别和只读属性
+	sql.TxOptions
+	// 遇到序列化失败或死锁时，重试整个事务的最大次数。默认0为不重试。
+	// 判断是否可重试，见IsRetryable函数。
+	MaxRetries int
+	// 每次重试前的等待时间
+	RetryInterval time.Duration
+}
+
+// WithTx 在事务中执行fn。fn返回nil则提交事务，返回错误或发生panic则回滚事务。
+// opts 为nil时，使用数据库驱动默认的隔离级别，且不重试。
+// 设置了opts.MaxRetries时，若事务因序列化失败或死锁而失败，将重新执行整个fn。因此fn应当可重复执行，不要在fn中修改外部状态。
+// 示例：
+//
+//	opts := &easydb.TxOptions{MaxRetries: 3}
+//	opts.Isolation = sql.LevelSerializable
+//	err := d.WithTx(ctx, opts, func(tx *easydb.EasyTx) error {
+//		var balance float64
+//		err := tx.GetOneContext(ctx, "SELECT wallet_balance FROM users WHERE id = $1", []interface{}{&balance}, 1)
+//		if err != nil {
+//			return err
+//		}
+//		_, err = tx.ExecContext(ctx, "UPDATE users SET wallet_balance = $1 WHERE id = $2", balance+10, 1)
+//		return err
+//	})
+func (d *EasyDb) WithTx(ctx context.Context, opts *TxOptions, fn func(tx *EasyTx) error) error {
+	var txopts *sql.TxOptions
+	maxRetries := 0
+	var retryInterval time.Duration
+	if opts != nil {
+		txopts = &opts.TxOptions
+		maxRetries = opts.MaxRetries
+		retryInterval = opts.RetryInterval
+	}
+	for attempt := 0; ; attempt++ {
+		err := d.withTxOnce(ctx, txopts, fn)
+		if err == nil || attempt >= maxRetries || !IsRetryable(err) {
+			return err
+		}
+		if d.loglevel > 0 {
+			log.Printf("Tx Retry(%d/%d): %v", attempt+1, maxRetries, err)
+		}
+		if retryInterval > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+}
+
+// withTxOnce 执行一次WithTx中的事务
+func (d *EasyDb) withTxOnce(ctx context.Context, opts *sql.TxOptions, fn func(tx *EasyTx) error) error {
+	tx, err := d.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	// 确保函数结束时要么提交要么回滚事务
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p) // 重新抛出panic
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rberr := tx.Rollback(); rberr != nil && !errors.Is(rberr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("回滚事务失败: %w", rberr))
+		}
+		return err
+	}
+
+	// 提交事务
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package easydb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// serializationErr 模拟postgres的序列化失败错误
+var serializationErr = &DbError{Driver: "postgres", Code: "40001", Kind: ErrorKindSerialization, Err: errors.New("could not serialize access")}
+
+func TestWithTxCommitRollback(t *testing.T) {
+	result := &fakeResult{}
+	d := newFakeDb(t, result)
+	ctx := context.Background()
+
+	err := d.WithTx(ctx, nil, func(tx *EasyTx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE users SET age = 1 WHERE id = 1")
+		return err
+	})
+	if want := []string{"BEGIN", "UPDATE users SET age = 1 WHERE id = 1", "COMMIT"}; err != nil || !reflect.DeepEqual(result.executed(), want) {
+		t.Errorf("WithTx commit got: %v", err)
+	}
+
+	fnErr := errors.New("fn failed")
+	err = d.WithTx(ctx, nil, func(tx *EasyTx) error {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET age = 1 WHERE id = 1"); err != nil {
+			return err
+		}
+		return fnErr
+	})
+	if want := []string{"BEGIN", "UPDATE users SET age = 1 WHERE id = 1", "ROLLBACK"}; !errors.Is(err, fnErr) || !reflect.DeepEqual(result.executed(), want) {
+		t.Errorf("WithTx rollback got: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("WithTx should re-panic, got: %v", p)
+			}
+			if want := []string{"BEGIN", "ROLLBACK"}; !reflect.DeepEqual(result.executed(), want) {
+				t.Errorf("WithTx panic should rollback")
+			}
+		}()
+		d.WithTx(ctx, nil, func(tx *EasyTx) error {
+			panic("boom")
+		})
+	}()
+}
+
+func TestWithTxRetry(t *testing.T) {
+	var failures atomic.Int32
+	result := &fakeResult{
+		columns: []string{"wallet_balance"},
+		rows:    [][]driver.Value{{float64(10)}},
+		err: func(query string) error {
+			if query == "SELECT wallet_balance FROM users WHERE id = $1" && failures.Add(-1) >= 0 {
+				return serializationErr
+			}
+			return nil
+		},
+	}
+	d := newFakeDb(t, result)
+	ctx := context.Background()
+	attempts := 0
+	fn := func(tx *EasyTx) error {
+		attempts++
+		var balance float64
+		return tx.GetOneContext(ctx, "SELECT wallet_balance FROM users WHERE id = $1", []interface{}{&balance}, 1)
+	}
+
+	// 前两次序列化失败，第三次成功
+	failures.Store(2)
+	if err := d.WithTx(ctx, &TxOptions{MaxRetries: 3}, fn); err != nil || attempts != 3 {
+		t.Errorf("WithTx retry got: %v, attempts %d", err, attempts)
+	}
+	if log := result.executed(); log[len(log)-1] != "COMMIT" {
+		t.Errorf("WithTx retry should commit at last, got: %v", log)
+	}
+
+	// 超过重试次数，返回最后一次的错误
+	attempts = 0
+	failures.Store(10)
+	if err := d.WithTx(ctx, &TxOptions{MaxRetries: 2}, fn); !IsRetryable(err) || attempts != 3 {
+		t.Errorf("WithTx MaxRetries got: %v, attempts %d", err, attempts)
+	}
+
+	// 未设置opts时不重试
+	attempts = 0
+	if err := d.WithTx(ctx, nil, fn); !IsRetryable(err) || attempts != 1 {
+		t.Errorf("WithTx without opts got: %v, attempts %d", err, attempts)
+	}
+
+	// 不可重试的错误不重试
+	attempts = 0
+	fnErr := errors.New("fn failed")
+	err := d.WithTx(ctx, &TxOptions{MaxRetries: 3}, func(tx *EasyTx) error {
+		attempts++
+		return fnErr
+	})
+	if !errors.Is(err, fnErr) || attempts != 1 {
+		t.Errorf("WithTx non-retryable got: %v, attempts %d", err, attempts)
+	}
+
+	// 重试等待期间ctx被取消，立即返回
+	attempts = 0
+	failures.Store(10)
+	cctx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	err = d.WithTx(cctx, &TxOptions{MaxRetries: 3, RetryInterval: time.Minute}, fn)
+	if !IsRetryable(err) || attempts != 1 || time.Since(start) > 10*time.Second {
+		t.Errorf("WithTx canceled during backoff got: %v, attempts %d", err, attempts)
+	}
+
+	// 等待RetryInterval后重试
+	attempts = 0
+	failures.Store(1)
+	start = time.Now()
+	err = d.WithTx(ctx, &TxOptions{MaxRetries: 1, RetryInterval: 30 * time.Millisecond}, fn)
+	if err != nil || attempts != 2 || time.Since(start) < 30*time.Millisecond {
+		t.Errorf("WithTx RetryInterval got: %v, attempts %d", err, attempts)
+	}
+}
+
+func TestNestedTx(t *testing.T) {
+	result := &fakeResult{}
+	d := newFakeDb(t, result)
+	cases := []struct {
+		dialect          string
+		commit, rollback []string
+	}{
+		{"postgres", []string{"SAVEPOINT easydb_sp_1", "RELEASE SAVEPOINT easydb_sp_1"},
+			[]string{"SAVEPOINT easydb_sp_2", "ROLLBACK TO SAVEPOINT easydb_sp_2", "RELEASE SAVEPOINT easydb_sp_2"}},
+		{"mysql", []string{"SAVEPOINT easydb_sp_1", "RELEASE SAVEPOINT easydb_sp_1"},
+			[]string{"SAVEPOINT easydb_sp_2", "ROLLBACK TO SAVEPOINT easydb_sp_2", "RELEASE SAVEPOINT easydb_sp_2"}},
+		{"sqlite3", []string{"SAVEPOINT easydb_sp_1", "RELEASE SAVEPOINT easydb_sp_1"},
+			[]string{"SAVEPOINT easydb_sp_2", "ROLLBACK TO SAVEPOINT easydb_sp_2", "RELEASE SAVEPOINT easydb_sp_2"}},
+		// sqlserver没有释放保存点的语句
+		{"sqlserver", []string{"SAVE TRANSACTION easydb_sp_1"},
+			[]string{"SAVE TRANSACTION easydb_sp_2", "ROLLBACK TRANSACTION easydb_sp_2"}},
+	}
+	for _, c := range cases {
+		d.SetDialect(GetDialect(c.dialect))
+		tx, err := d.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.IsNested() || result.executed()[0] != "BEGIN" {
+			t.Fatalf("%s Begin should start a transaction", c.dialect)
+		}
+
+		inner, err := tx.Begin()
+		if err != nil || !inner.IsNested() {
+			t.Fatalf("%s nested Begin got: %v", c.dialect, err)
+		}
+		if err = inner.Commit(); err != nil || !reflect.DeepEqual(result.executed(), c.commit) {
+			t.Errorf("%s nested Commit got: %v", c.dialect, err)
+		}
+		if err = inner.Commit(); !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("%s nested Commit twice got: %v", c.dialect, err)
+		}
+		if _, err = inner.BeginContext(context.Background()); !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("%s Begin on done nested tx got: %v", c.dialect, err)
+		}
+
+		inner, err = tx.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = inner.Rollback(); err != nil || !reflect.DeepEqual(result.executed(), c.rollback) {
+			t.Errorf("%s nested Rollback got: %v", c.dialect, err)
+		}
+		if err = inner.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("%s nested Rollback twice got: %v", c.dialect, err)
+		}
+
+		// 嵌套事务回滚后，外层事务可继续执行并提交
+		if _, err = tx.Exec("UPDATE users SET age = 1 WHERE id = 1"); err != nil {
+			t.Fatal(err)
+		}
+		if err = tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if got := result.executed(); !reflect.DeepEqual(got, []string{"UPDATE users SET age = 1 WHERE id = 1", "COMMIT"}) {
+			t.Errorf("%s outer tx after nested Rollback got: %v", c.dialect, got)
+		}
+		if err = tx.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("%s Rollback after Commit got: %v", c.dialect, err)
+		}
+	}
+}
+
+func TestNestedWithTx(t *testing.T) {
+	result := &fakeResult{}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+	ctx := context.Background()
+	fnErr := errors.New("fn failed")
+	err := d.WithTx(ctx, nil, func(tx *EasyTx) error {
+		err := tx.WithTx(ctx, func(inner *EasyTx) error {
+			if _, err := inner.ExecContext(ctx, "INSERT INTO logs (msg) VALUES ($1)", "hello"); err != nil {
+				return err
+			}
+			return fnErr
+		})
+		if !errors.Is(err, fnErr) {
+			t.Errorf("nested WithTx got: %v", err)
+		}
+		return tx.WithTx(ctx, func(inner *EasyTx) error {
+			_, err := inner.ExecContext(ctx, "UPDATE users SET age = 1 WHERE id = 1")
+			return err
+		})
+	})
+	want := []string{"BEGIN",
+		"SAVEPOINT easydb_sp_1", "INSERT INTO logs (msg) VALUES ($1)", "ROLLBACK TO SAVEPOINT easydb_sp_1", "RELEASE SAVEPOINT easydb_sp_1",
+		"SAVEPOINT easydb_sp_2", "UPDATE users SET age = 1 WHERE id = 1", "RELEASE SAVEPOINT easydb_sp_2",
+		"COMMIT"}
+	if got := result.executed(); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("nested WithTx got: %v %v", err, got)
+	}
+}
+
+func TestEasyTxMethods(t *testing.T) {
+	result := &fakeResult{columns: []string{"id", "name"}, rows: [][]driver.Value{{int64(1), "Tom"}}}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+	// 只有一个连接，不在事务中执行的语句将等待至超时
+	d.GetSqlDB().SetMaxOpenConns(1)
+	d.SetTimeout(time.Second)
+
+	tx, err := d.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec("UPDATE users SET age = 1 WHERE id = $1", 1); err != nil {
+		t.Errorf("Exec got: %v", err)
+	}
+	rows, err := tx.Query("SELECT id, name FROM users")
+	if err != nil {
+		t.Fatalf("Query got: %v", err)
+	}
+	rows.Close()
+	var id int64
+	var name string
+	if err = tx.QueryRow("SELECT id, name FROM users WHERE id = $1", 1).Scan(&id, &name); err != nil || name != "Tom" {
+		t.Errorf("QueryRow got: %s %v", name, err)
+	}
+	if err = tx.GetOne("SELECT id, name FROM users WHERE id = $1", []interface{}{&id, &name}, 1); err != nil {
+		t.Errorf("GetOne got: %v", err)
+	}
+	user := make(map[string]interface{})
+	if err = tx.GetOneData("SELECT id, name FROM users WHERE id = $1", user, 1); err != nil || user["name"] != "Tom" {
+		t.Errorf("GetOneData got: %v %v", user, err)
+	}
+	var users []map[string]interface{}
+	if err = tx.GetMany("SELECT id, name FROM users", &users); err != nil || len(users) != 1 {
+		t.Errorf("GetMany got: %v %v", users, err)
+	}
+	if err = tx.ExecInsert("users", []string{"name"}, []interface{}{"Tom"}); err != nil {
+		t.Errorf("ExecInsert got: %v", err)
+	}
+	if err = tx.ExecUpdateByValues("users", []string{"name"}, []interface{}{"Tom"}, "id = $2", []interface{}{1}); err != nil {
+		t.Errorf("ExecUpdateByValues got: %v", err)
+	}
+	if _, err = tx.ExecDelete("users", "id = $1", []interface{}{1}); err != nil {
+		t.Errorf("ExecDelete got: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	log := result.executed()
+	if len(log) != 11 || log[0] != "BEGIN" || log[10] != "COMMIT" {
+		t.Errorf("EasyTx methods should run in the transaction: %v", log)
+	}
+}
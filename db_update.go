@@ -1,29 +1,41 @@
 package easydb
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 )
 
+// ErrNoRowsUpdated ExecUpdateByValues没有更新任何记录时返回
+var ErrNoRowsUpdated = errors.New("没有记录被更新")
+
 // ExecInsert 执行单条插入语句
 func (d *EasyDb) ExecInsert(tableName string, columns []string, values []interface{}) error {
-	// 构建插入SQL语句
-	placeholders := make([]string, len(values))
-	for i := range values {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-	}
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.ExecInsertContext(ctx, tableName, columns, values)
+}
 
+// ExecInsertContext 同ExecInsert，使用ctx控制执行的取消和超时
+func (d *EasyDb) ExecInsertContext(ctx context.Context, tableName string, columns []string, values []interface{}) error {
+	return d.execInsert(ctx, d.db, tableName, columns, values)
+}
+
+// execInsert ExecInsert的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) execInsert(ctx context.Context, conn sqlConn, tableName string, columns []string, values []interface{}) error {
+	// 构建插入SQL语句
 	sqlText := fmt.Sprintf(
 		"INSERT INTO %s (%s) VALUES (%s)",
 		tableName,
 		strings.Join(columns, ", "),
-		strings.Join(placeholders, ", "),
+		placeholders(d.dialect, 0, len(values)),
 	)
 
 	// 执行插入操作
-	_, err := d.db.Exec(sqlText, values...)
+	_, err := d.execBound(ctx, conn, sqlText, values...)
 	if err != nil {
-		return fmt.Errorf("插入数据失败: %v", err)
+		return fmt.Errorf("插入数据失败: %w", err)
 	}
 
 	return nil
@@ -33,15 +45,31 @@ func (d *EasyDb) ExecInsert(tableName string, columns []string, values []interfa
 // tableName: 表名
 // columns: 要更新的列名
 // values: 要更新的值
-// whereClause: WHERE子句。例如："id = $1"
+// whereClause: WHERE子句。例如：mysql为"id = ?"。postgres的占位符序号接在columns之后，更新2列时为"id = $3"
 // whereValues: WHERE子句中的参数值。例如：[]interface{}{1}
+// 没有记录被更新时，返回ErrNoRowsUpdated
 func (d *EasyDb) ExecUpdateByValues(tableName string, columns []string, values []interface{}, whereClause string, whereValues []interface{}) error {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.ExecUpdateByValuesContext(ctx, tableName, columns, values, whereClause, whereValues)
+}
+
+// ExecUpdateByValuesContext 同ExecUpdateByValues，使用ctx控制执行的取消和超时
+func (d *EasyDb) ExecUpdateByValuesContext(ctx context.Context, tableName string, columns []string, values []interface{}, whereClause string, whereValues []interface{}) error {
+	return d.execUpdateByValues(ctx, d.db, tableName, columns, values, whereClause, whereValues)
+}
+
+// execUpdateByValues ExecUpdateByValues的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) execUpdateByValues(ctx context.Context, conn sqlConn, tableName string, columns []string, values []interface{}, whereClause string, whereValues []interface{}) error {
 	// 构建SET子句
 	setClause := make([]string, len(columns))
-	paramCount := 1
 	for i, col := range columns {
-		setClause[i] = fmt.Sprintf("%s = $%d", col, paramCount)
-		paramCount++
+		setClause[i] = fmt.Sprintf("%s = %s", col, d.dialect.Placeholder(i))
+	}
+
+	whereClause, whereValues, err := d.bindQuery(whereClause, whereValues, len(columns))
+	if err != nil {
+		return err
 	}
 
 	// 构建完整的更新SQL语句
@@ -53,23 +81,59 @@ func (d *EasyDb) ExecUpdateByValues(tableName string, columns []string, values [
 	)
 
 	// 合并values和whereValues
-	allValues := append(values, whereValues...)
+	allValues := make([]interface{}, 0, len(values)+len(whereValues))
+	allValues = append(allValues, values...)
+	allValues = append(allValues, whereValues...)
 
 	// 执行更新操作
-	result, err := d.db.Exec(sqlText, allValues...)
+	result, err := d.execBound(ctx, conn, sqlText, allValues...)
 	if err != nil {
-		return fmt.Errorf("更新数据失败: %v", err)
+		return fmt.Errorf("更新数据失败: %w", err)
 	}
 
 	// 检查受影响的行数
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("获取受影响行数失败: %v", err)
+		return fmt.Errorf("获取受影响行数失败: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("没有记录被更新")
+		return ErrNoRowsUpdated
 	}
 
 	return nil
 }
+
+// ExecDelete 执行删除语句，返回删除的行数
+// tableName: 表名
+// whereClause: WHERE子句，不能为空。例如：mysql为"id = ?"，postgres为"id = $1"
+// whereValues: WHERE子句中的参数值。例如：[]interface{}{1}
+func (d *EasyDb) ExecDelete(tableName string, whereClause string, whereValues []interface{}) (int64, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.ExecDeleteContext(ctx, tableName, whereClause, whereValues)
+}
+
+// ExecDeleteContext 同ExecDelete，使用ctx控制执行的取消和超时
+func (d *EasyDb) ExecDeleteContext(ctx context.Context, tableName string, whereClause string, whereValues []interface{}) (int64, error) {
+	return d.execDelete(ctx, d.db, tableName, whereClause, whereValues)
+}
+
+// execDelete ExecDelete的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) execDelete(ctx context.Context, conn sqlConn, tableName string, whereClause string, whereValues []interface{}) (int64, error) {
+	// 不允许无条件删除整张表的数据
+	if strings.TrimSpace(whereClause) == "" {
+		return 0, ErrMissingWhere
+	}
+	whereClause, whereValues, err := d.bindQuery(whereClause, whereValues, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	sqlText := fmt.Sprintf("DELETE FROM %s WHERE %s", tableName, whereClause)
+	result, err := d.execBound(ctx, conn, sqlText, whereValues...)
+	if err != nil {
+		return 0, fmt.Errorf("删除数据失败: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,22 @@
+package easydb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNoRowsUpdated(t *testing.T) {
+	var affected int64
+	result := &fakeResult{rowsAffected: func(query string) int64 { return affected }}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+
+	err := d.ExecUpdateByValues("users", []string{"name"}, []interface{}{"Tom"}, "id = $2", []interface{}{1})
+	if !errors.Is(err, ErrNoRowsUpdated) {
+		t.Errorf("ExecUpdateByValues got: %v", err)
+	}
+	affected = 1
+	if err = d.ExecUpdateByValues("users", []string{"name"}, []interface{}{"Tom"}, "id = $2", []interface{}{1}); err != nil {
+		t.Errorf("ExecUpdateByValues got: %v", err)
+	}
+}
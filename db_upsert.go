@@ -0,0 +1,165 @@
+package easydb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Upsert 插入一行数据，与conflictColumns列的唯一约束冲突时，更新updateColumns列。返回受影响的行数。
+// updateColumns为空时，更新除conflictColumns以外的所有列。
+// postgres, sqlite3 使用 ON CONFLICT (...) DO UPDATE，mysql 使用 ON DUPLICATE KEY UPDATE，sqlserver, oracle 使用 MERGE。
+// mysql根据表的主键和唯一索引判断冲突，可不传conflictColumns，其他数据库必须传入。
+// 注意：mysql更新已存在的行时，受影响的行数为2。
+// 示例：
+//
+//	affected, err := d.Upsert("users", []string{"id", "name", "age"}, []interface{}{1, "Tom", 18}, []string{"id"}, nil)
+func (d *EasyDb) Upsert(table string, columns []string, values []interface{}, conflictColumns, updateColumns []string) (int64, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.UpsertContext(ctx, table, columns, values, conflictColumns, updateColumns)
+}
+
+// UpsertContext 同Upsert，使用ctx控制执行的取消和超时
+func (d *EasyDb) UpsertContext(ctx context.Context, table string, columns []string, values []interface{}, conflictColumns, updateColumns []string) (int64, error) {
+	return d.upsert(ctx, d.db, table, columns, values, conflictColumns, updateColumns, false)
+}
+
+// InsertIgnore 插入一行数据，与conflictColumns列的唯一约束冲突时，不做任何操作。返回受影响的行数，冲突时为0。
+// postgres, sqlite3 使用 ON CONFLICT (...) DO NOTHING，mysql 使用 INSERT IGNORE，sqlserver, oracle 使用 MERGE。
+// postgres, sqlite3, mysql 可不传conflictColumns，表示任意唯一约束冲突。
+// 注意：mysql的INSERT IGNORE还会忽略其他错误，如数据截断。
+func (d *EasyDb) InsertIgnore(table string, columns []string, values []interface{}, conflictColumns []string) (int64, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.InsertIgnoreContext(ctx, table, columns, values, conflictColumns)
+}
+
+// InsertIgnoreContext 同InsertIgnore，使用ctx控制执行的取消和超时
+func (d *EasyDb) InsertIgnoreContext(ctx context.Context, table string, columns []string, values []interface{}, conflictColumns []string) (int64, error) {
+	return d.upsert(ctx, d.db, table, columns, values, conflictColumns, nil, true)
+}
+
+// upsertSQL 生成Upsert或InsertIgnore的SQL语句
+func upsertSQL(dl Dialect, table string, columns []string, valueCount int, conflictColumns, updateColumns []string, ignore bool) (string, error) {
+	if len(columns) == 0 {
+		return "", fmt.Errorf("插入的列不能为空")
+	}
+	if len(columns) != valueCount {
+		return "", fmt.Errorf("值的数量(%d)与列的数量(%d)不一致", valueCount, len(columns))
+	}
+	name := dl.Name()
+	merge := name == "sqlserver" || name == "oracle"
+	if len(conflictColumns) == 0 {
+		if merge || (!ignore && name != "mysql") {
+			return "", fmt.Errorf("数据库%s必须指定冲突判断的列conflictColumns", name)
+		}
+	}
+	if merge {
+		// MERGE语句按插入的值判断冲突，冲突判断的列必须在插入的列中
+		for _, col := range conflictColumns {
+			if !slices.Contains(columns, col) {
+				return "", fmt.Errorf("数据库%s的冲突判断的列%s不在插入的列中", name, col)
+			}
+		}
+	}
+	if ignore {
+		updateColumns = nil
+	} else if len(updateColumns) == 0 {
+		for _, col := range columns {
+			if !slices.Contains(conflictColumns, col) {
+				updateColumns = append(updateColumns, col)
+			}
+		}
+		if len(updateColumns) == 0 {
+			return "", fmt.Errorf("没有可更新的列，请使用InsertIgnore")
+		}
+	}
+	return dl.Upsert(table, columns, conflictColumns, updateColumns), nil
+}
+
+// upsert Upsert和InsertIgnore的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) upsert(ctx context.Context, conn sqlConn, table string, columns []string, values []interface{}, conflictColumns, updateColumns []string, ignore bool) (int64, error) {
+	query, err := upsertSQL(d.dialect, table, columns, len(values), conflictColumns, updateColumns, ignore)
+	if err != nil {
+		return 0, err
+	}
+	result, err := d.execBound(ctx, conn, query, values...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// UpsertStruct 根据结构体的db标签插入一行数据，冲突时更新其他字段。src为结构体指针。
+// conflictColumns为空时，使用pk字段判断冲突。字段的处理同InsertStruct，数据库生成的主键不会回写到结构体。
+// sqlserver, oracle的冲突判断的列必须在插入的列中：单一主键为零值时不插入主键，须通过conflictColumns指定其他列，否则返回错误。
+// 示例：
+//
+//	user := User{ID: 1, Name: "Tom", Age: 18}
+//	affected, err := d.UpsertStruct("users", &user)
+//	affected, err = d.UpsertStruct("users", &user, "name")
+func (d *EasyDb) UpsertStruct(table string, src interface{}, conflictColumns ...string) (int64, error) {
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.UpsertStructContext(ctx, table, src, conflictColumns...)
+}
+
+// UpsertStructContext 同UpsertStruct，使用ctx控制执行的取消和超时
+func (d *EasyDb) UpsertStructContext(ctx context.Context, table string, src interface{}, conflictColumns ...string) (int64, error) {
+	return d.upsertStruct(ctx, d.db, table, src, conflictColumns)
+}
+
+// upsertStruct UpsertStruct的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) upsertStruct(ctx context.Context, conn sqlConn, table string, src interface{}, conflictColumns []string) (int64, error) {
+	v, err := structValue(src)
+	if err != nil {
+		return 0, err
+	}
+	fields := getStructFields(v.Type())
+	pks := structPks(fields)
+	if len(conflictColumns) == 0 {
+		for _, pk := range pks {
+			conflictColumns = append(conflictColumns, pk.tag.name)
+		}
+	}
+	autoPk := len(pks) == 1 && pks[0].value(v).IsZero()
+	columns, values := structWriteColumns(v, fields, autoPk)
+	return d.upsert(ctx, conn, table, columns, values, conflictColumns, nil, false)
+}
+
+// Upsert 在事务中插入或更新一行数据。用法同EasyDb.Upsert
+func (t *EasyTx) Upsert(table string, columns []string, values []interface{}, conflictColumns, updateColumns []string) (int64, error) {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.UpsertContext(ctx, table, columns, values, conflictColumns, updateColumns)
+}
+
+// UpsertContext 同Upsert，使用ctx控制执行的取消和超时
+func (t *EasyTx) UpsertContext(ctx context.Context, table string, columns []string, values []interface{}, conflictColumns, updateColumns []string) (int64, error) {
+	return t.db.upsert(ctx, t.tx, table, columns, values, conflictColumns, updateColumns, false)
+}
+
+// InsertIgnore 在事务中插入一行数据，冲突时不做任何操作。用法同EasyDb.InsertIgnore
+func (t *EasyTx) InsertIgnore(table string, columns []string, values []interface{}, conflictColumns []string) (int64, error) {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.InsertIgnoreContext(ctx, table, columns, values, conflictColumns)
+}
+
+// InsertIgnoreContext 同InsertIgnore，使用ctx控制执行的取消和超时
+func (t *EasyTx) InsertIgnoreContext(ctx context.Context, table string, columns []string, values []interface{}, conflictColumns []string) (int64, error) {
+	return t.db.upsert(ctx, t.tx, table, columns, values, conflictColumns, nil, true)
+}
+
+// UpsertStruct 在事务中根据结构体的db标签插入或更新一行数据。用法同EasyDb.UpsertStruct
+func (t *EasyTx) UpsertStruct(table string, src interface{}, conflictColumns ...string) (int64, error) {
+	ctx, cancel := t.db.newContext()
+	defer cancel()
+	return t.UpsertStructContext(ctx, table, src, conflictColumns...)
+}
+
+// UpsertStructContext 同UpsertStruct，使用ctx控制执行的取消和超时
+func (t *EasyTx) UpsertStructContext(ctx context.Context, table string, src interface{}, conflictColumns ...string) (int64, error) {
+	return t.db.upsertStruct(ctx, t.tx, table, src, conflictColumns)
+}
@@ -0,0 +1,61 @@
+package easydb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpsertSQL(t *testing.T) {
+	pg := GetDialect("postgres")
+	query, err := upsertSQL(pg, "users", []string{"id", "name", "age"}, 3, []string{"id"}, nil, false)
+	want := "INSERT INTO users (id, name, age) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, age = EXCLUDED.age"
+	if err != nil || query != want {
+		t.Errorf("upsertSQL got: %s %v", query, err)
+	}
+	query, err = upsertSQL(pg, "users", []string{"id", "name"}, 2, nil, []string{"name"}, true)
+	if err != nil || query != "INSERT INTO users (id, name) VALUES ($1, $2) ON CONFLICT DO NOTHING" {
+		t.Errorf("upsertSQL ignore got: %s %v", query, err)
+	}
+	if _, err = upsertSQL(pg, "users", []string{"id", "name"}, 2, nil, nil, false); err == nil {
+		t.Error("upsertSQL postgres should require conflictColumns")
+	}
+	if _, err = upsertSQL(GetDialect("mysql"), "users", []string{"id", "name"}, 2, nil, nil, false); err != nil {
+		t.Errorf("upsertSQL mysql got: %v", err)
+	}
+	if _, err = upsertSQL(GetDialect("sqlserver"), "users", []string{"id", "name"}, 2, nil, nil, true); err == nil {
+		t.Error("upsertSQL sqlserver should require conflictColumns")
+	}
+	if _, err = upsertSQL(pg, "users", []string{"id"}, 1, []string{"id"}, nil, false); err == nil {
+		t.Error("upsertSQL should fail without update columns")
+	}
+}
+
+func TestUpsertStructMerge(t *testing.T) {
+	result := &fakeResult{}
+	d := newFakeDb(t, result)
+	for _, name := range []string{"sqlserver", "oracle"} {
+		d.SetDialect(GetDialect(name))
+		// 主键为零值时不插入主键，不能作为MERGE的冲突判断的列
+		if _, err := d.UpsertStruct("users", &structTagUser{Name: "Tom"}); err == nil || !strings.Contains(err.Error(), "id") {
+			t.Errorf("%s UpsertStruct with zero pk got: %v", name, err)
+		}
+		if _, err := d.Upsert("users", []string{"name"}, []interface{}{"Tom"}, []string{"id"}, nil); err == nil {
+			t.Errorf("%s Upsert should fail when conflict column is not inserted", name)
+		}
+		if _, err := d.UpsertStruct("users", &structTagUser{Name: "Tom", Age: 18}, "name"); err != nil {
+			t.Errorf("%s UpsertStruct with conflictColumns got: %v", name, err)
+		}
+		if _, err := d.UpsertStruct("users", &structTagUser{ID: 1, Name: "Tom"}); err != nil {
+			t.Errorf("%s UpsertStruct with pk got: %v", name, err)
+		}
+	}
+	if log := result.executed(); len(log) != 4 {
+		t.Errorf("UpsertStruct executed: %q", log)
+	}
+
+	// ON CONFLICT 不要求冲突判断的列在插入的列中
+	d.SetDialect(GetDialect("postgres"))
+	if _, err := d.UpsertStruct("users", &structTagUser{Name: "Tom"}); err != nil {
+		t.Errorf("postgres UpsertStruct with zero pk got: %v", err)
+	}
+}
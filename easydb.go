@@ -1,7 +1,9 @@
 package easydb
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"log"
 	"strings"
@@ -33,9 +35,41 @@ func SetEasyDb(edb *EasyDb) {
 	instance = edb
 }
 
+// sqlConn *sql.DB和*sql.Tx共有的方法。EasyDb和EasyTx共用同一套查询、扫描逻辑。
+type sqlConn interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
 type EasyDb struct {
-	db       *sql.DB
-	loglevel int
+	db         *sql.DB
+	driverName string
+	dialect    Dialect
+	rebind     bool
+	// 空切片参数替换为NULL
+	emptySliceAsNull bool
+	// 单条语句的最大参数数量，0表示使用方言的设置
+	maxParams int
+	// 写操作保护
+	guard WriteGuard
+	// 查询不到数据时返回ErrNotFound
+	notFoundAsError bool
+	// 查询结果为NULL而接收的变量不能表示NULL时返回错误，默认写入零值
+	nullAsError bool
+	// 查询结果的列与结构体字段的对应规则，nil表示使用默认规则
+	mapper *structMapper
+	// 按数据库类型名称自定义的列值解码函数
+	decoders map[string]ColumnDecoder
+	// DECIMAL等定点数类型的列的解码方式
+	decimalMode DecimalMode
+	// 自定义类型的转换函数
+	converters converters
+	// 不支持数组类型的数据库中，数组的存储格式
+	arrayFormat ArrayFormat
+	loglevel    int
+	timeout     time.Duration
 }
 
 // SowLog 展示运行日志。默认0为不展示。数值越大越详细。
@@ -43,6 +77,85 @@ func (d *EasyDb) SowLog(level int) {
 	d.loglevel = level
 }
 
+// SetTimeout 设置默认超时时间。默认0为不超时。
+// 仅对不带Context后缀的方法生效，如Query, Exec, GetMany等。带Context后缀的方法，以传入的ctx为准。
+func (d *EasyDb) SetTimeout(timeout time.Duration) {
+	d.timeout = timeout
+}
+
+// errArg 转换为数据库驱动的参数值时返回err。用于使*sql.Row携带SQL语句未执行的原因
+type errArg struct {
+	err error
+}
+
+// Value 实现driver.Valuer接口
+func (a errArg) Value() (driver.Value, error) {
+	return nil, a.err
+}
+
+// newContext 为不带Context后缀的方法创建上下文。设置了默认超时时间时，附带超时控制。
+func (d *EasyDb) newContext() (context.Context, context.CancelFunc) {
+	if d.timeout > 0 {
+		return context.WithTimeout(context.Background(), d.timeout)
+	}
+	return context.Background(), func() {}
+}
+
+// newQueryContext 为Query, QueryRow创建上下文。返回的rows在方法返回后才读取，无法在读取完成时取消ctx。
+// 设置了默认超时时间时，ctx的计时器在超时后取消ctx并释放其资源；未设置时为context.Background()，无需释放。
+func (d *EasyDb) newQueryContext() context.Context {
+	ctx, _ := d.newContext() // 由超时计时器释放
+	return ctx
+}
+
+// DriverName 获取数据库类型：postgres, mysql, sqlite3, sqlserver, oracle。
+// 根据数据库驱动自动识别，无法识别时为初始化时传入的driverName，或为空。
+func (d *EasyDb) DriverName() string {
+	return d.driverName
+}
+
+// Dialect 获取当前使用的数据库方言
+func (d *EasyDb) Dialect() Dialect {
+	return d.dialect
+}
+
+// SetDialect 设置数据库方言。初始化时已根据数据库驱动自动选择，通常无需设置。
+// 示例：
+//
+//	d := easydb.NewEasyDbBySqlDB(sqldb)
+//	d.SetDialect(easydb.GetDialect("postgres"))
+func (d *EasyDb) SetDialect(dl Dialect) {
+	d.dialect = dl
+}
+
+// SetRebind 开启后，执行SQL语句前将?占位符转换为方言对应的占位符。默认不开启。
+// 开启后可统一使用?占位符编写SQL，同一套SQL语句可运行于mysql和postgres等不同的数据库。
+// 字符串、引号标识符和注释中的?保持不变。需要字面量?时（如postgres的JSON操作符?），使用??转义。
+// 作用于Query, QueryRow, Exec, GetOne, GetOneData, GetMany，以及ExecUpdateByValues的whereClause。
+func (d *EasyDb) SetRebind(enable bool) {
+	d.rebind = enable
+}
+
+// SetEmptySliceAsNull 设置空切片参数的处理方式。
+// 默认返回ErrEmptySlice错误。开启后，空切片替换为NULL，如 IN (NULL) 不匹配任何数据。
+// 注意 NOT IN (NULL) 同样不匹配任何数据。
+func (d *EasyDb) SetEmptySliceAsNull(enable bool) {
+	d.emptySliceAsNull = enable
+}
+
+// SetNotFoundAsError 设置GetOne, GetOneData查询不到数据时的处理方式。
+// 默认返回nil，dest保持不变，无法区分查询不到数据和查询到零值的数据。开启后返回ErrNotFound，可使用errors.Is判断。
+// 同样作用于SelectBuilder的GetOne, GetOneData，以及ExecReturning接收单行数据时。
+func (d *EasyDb) SetNotFoundAsError(enable bool) {
+	d.notFoundAsError = enable
+}
+
+// SetMaxParams 设置单条语句的最大参数数量，批量插入时据此拆分语句。默认0，使用方言的设置（Dialect.MaxParams）。
+// 如SQLite 3.32及以上版本，可设置为32766以减少拆分的语句数量。
+func (d *EasyDb) SetMaxParams(n int) {
+	d.maxParams = n
+}
+
 // GetSqlDB 获取*sql.DB实例
 func (d *EasyDb) GetSqlDB() *sql.DB {
 	return d.db
@@ -99,7 +212,12 @@ func NewEasyDbByConf(cf DsnConf) *EasyDb {
 	if err != nil {
 		panic(err)
 	}
-	return NewEasyDbBySqlDB(sqldb)
+	d := NewEasyDbBySqlDB(sqldb)
+	if d.driverName == "" {
+		d.driverName = cf.DriverName
+		d.dialect = GetDialect(cf.DriverName)
+	}
+	return d
 }
 
 // NewEasyDbBySqlDB 使用sqldb *sql.DB参数初始化EasyDb实例。
@@ -118,30 +236,84 @@ func NewEasyDbByConf(cf DsnConf) *EasyDb {
 //	//  sqldb, err := sql.Open("sqlite3", "./mydb.sqlite")
 //	d := NewEasyDbBySqlDB(sqldb)
 func NewEasyDbBySqlDB(sqldb *sql.DB) *EasyDb {
-	return &EasyDb{db: sqldb}
+	driverName := getDriverName(sqldb)
+	return &EasyDb{db: sqldb, driverName: driverName, dialect: GetDialect(driverName)}
 }
 
 // Query 重写Query方法以记录SQL查询
+// 设置了默认超时时间时，返回的rows在超时后不可再读取。
 func (d *EasyDb) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.QueryContext(d.newQueryContext(), query, args...)
+}
+
+// QueryContext 同Query，使用ctx控制查询的取消和超时
+func (d *EasyDb) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return d.query(ctx, d.db, query, args...)
+}
+
+// query Query的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) query(ctx context.Context, conn sqlConn, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args, err := d.bindQuery(query, args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return d.queryBound(ctx, conn, query, args...)
+}
+
+// queryBound 使用已处理好占位符的SQL语句执行查询
+func (d *EasyDb) queryBound(ctx context.Context, conn sqlConn, query string, args ...interface{}) (*sql.Rows, error) {
+	if err := d.guardQuery(query); err != nil {
+		return nil, err
+	}
+	args = d.driverArgs(args)
 	start := time.Now()
 	if d.loglevel > 1 {
 		log.Printf("Query SQL: (%s) args: (%v)", query, args)
 	}
-	rows, err := d.db.Query(query, args...)
+	rows, err := conn.QueryContext(ctx, query, args...)
 	if d.loglevel > 0 {
 		log.Printf("Query Done. Cost: %v", time.Since(start))
 	}
 	return rows, err
 }
 
-// QueryRow 重写QueryRow方法以记录SQL查询
+// QueryRow 重写QueryRow方法以记录SQL查询。
+// 绑定参数失败（如空切片返回ErrEmptySlice），或写操作保护拒绝 UPDATE/DELETE ... RETURNING 语句（ErrMissingWhere）时，
+// SQL语句不会执行，row.Scan返回的错误可使用errors.Is判断。
 func (d *EasyDb) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.QueryRowContext(d.newQueryContext(), query, args...)
+}
+
+// QueryRowContext 同QueryRow，使用ctx控制查询的取消和超时
+func (d *EasyDb) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return d.queryRow(ctx, d.db, query, args...)
+}
+
+// queryRow QueryRow的实现，conn可以是*sql.DB或*sql.Tx
+func (d *EasyDb) queryRow(ctx context.Context, conn sqlConn, query string, args ...interface{}) *sql.Row {
 	// log.Printf("查询单行SQL: %s 参数: %v", query, args)
-	return d.db.QueryRow(query, args...)
+	bquery, bargs, err := d.bindQuery(query, args, 0)
+	if err != nil {
+		// *sql.Row无法直接携带错误：数据库驱动转换参数时返回err，SQL语句不会执行
+		return conn.QueryRowContext(ctx, query, errArg{err: err})
+	}
+	query, args = bquery, bargs
+	if err := d.guardQuery(query); err != nil {
+		// 写操作保护拒绝的语句不能执行，同绑定参数失败
+		return conn.QueryRowContext(ctx, query, errArg{err: err})
+	}
+	return conn.QueryRowContext(ctx, query, d.driverArgs(args)...)
 }
 
 func (d *EasyDb) Ping() error {
-	return d.db.Ping()
+	ctx, cancel := d.newContext()
+	defer cancel()
+	return d.PingContext(ctx)
+}
+
+// PingContext 同Ping，使用ctx控制超时
+func (d *EasyDb) PingContext(ctx context.Context) error {
+	return d.db.PingContext(ctx)
 }
 
 // CloseDb 关闭整个数据库连接池
@@ -1,12 +1,32 @@
 package easydb
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"testing"
+	"time"
 	// _ "github.com/lib/pq"
 )
 
+// postgresTestHost 连接postgres的集成测试的数据库地址，取自环境变量EASYDB_TEST_POSTGRES_HOST。
+// 未设置或未注册postgres驱动时跳过测试
+func postgresTestHost(t *testing.T) string {
+	t.Helper()
+	host := os.Getenv("EASYDB_TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("未设置EASYDB_TEST_POSTGRES_HOST，跳过postgres集成测试")
+	}
+	if !slices.Contains(sql.Drivers(), "postgres") {
+		t.Skip("未注册postgres驱动，跳过postgres集成测试")
+	}
+	return host
+}
+
 func TestPostgresAdd(t *testing.T) {
 	var sqldb *sql.DB
 	var err error
@@ -17,7 +37,8 @@ func TestPostgresAdd(t *testing.T) {
 		wallet_balance DECIMAL(10,2) DEFAULT 0.00
     )`
 
-	sqldb, err = sql.Open("postgres", "user=postgres password=postgres dbname=postgres host=127.0.0.1 port=5432 sslmode=disable search_path=public")
+	host := postgresTestHost(t)
+	sqldb, err = sql.Open("postgres", "user=postgres password=postgres dbname=postgres host="+host+" port=5432 sslmode=disable search_path=public")
 	if err != nil {
 		t.Error(err)
 	}
@@ -40,13 +61,14 @@ func TestPostgresAdd(t *testing.T) {
 }
 
 func TestPostgresQuery(t *testing.T) {
-	TestPostgresDb(t, "postgres")
-	TestPostgresDb(t, "odoo")
+	host := postgresTestHost(t)
+	testPostgresDb(t, host, "postgres")
+	testPostgresDb(t, host, "odoo")
 }
 
 func TestPostgresQueryList(t *testing.T) {
 	var err error
-	d := NewEasyDb("postgres", "127.0.0.1", "postgres", "postgres", "postgres", 5432)
+	d := NewEasyDb("postgres", postgresTestHost(t), "postgres", "postgres", "postgres", 5432)
 	var username []string
 	err = d.GetMany("SELECT name FROM users", &username)
 	if err != nil {
@@ -82,9 +104,9 @@ func TestPostgresQueryList(t *testing.T) {
 	t.Logf("---TestPostgresQueryList--users-Result(%+v)---", datalist)
 }
 
-func TestPostgresDb(t *testing.T, dbname string) {
+func testPostgresDb(t *testing.T, host, dbname string) {
 	var err error
-	d := NewEasyDb("postgres", "127.0.0.1", "postgres", "postgres", dbname, 5432)
+	d := NewEasyDb("postgres", host, "postgres", "postgres", dbname, 5432)
 	data := make(map[string]any, 2)
 	// FROM 后面不能放占位符，应接真实的数据表名。否则报错【预处理SQL语句失败: pq: 语法错误 在 "$1" 或附近的】
 	// err = d.GetOneData("SELECT id, name, age, wallet_balance FROM users WHERE id = $1", &data, 10)
@@ -122,3 +144,94 @@ type User struct {
 	Age           int     `db:"age"`
 	WalletBalance float64 `db:"wallet_balance"`
 }
+
+func TestContextCancel(t *testing.T) {
+	result := &fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+
+	// 已取消的ctx，不执行任何SQL语句
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var ids []int64
+	if err := d.GetManyContext(ctx, "SELECT id FROM users", &ids); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetManyContext got: %v", err)
+	}
+	var id int64
+	if err := d.GetOneContext(ctx, "SELECT id FROM users WHERE id = $1", []interface{}{&id}, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetOneContext got: %v", err)
+	}
+	if _, err := d.ExecContext(ctx, "UPDATE users SET age = 1 WHERE id = 1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExecContext got: %v", err)
+	}
+	if _, err := d.QueryContext(ctx, "SELECT id FROM users"); !errors.Is(err, context.Canceled) {
+		t.Errorf("QueryContext got: %v", err)
+	}
+	if log := result.executed(); len(log) != 0 {
+		t.Errorf("canceled ctx should not execute: %v", log)
+	}
+
+	// SetTimeout设置的默认超时时间
+	d.SetTimeout(20 * time.Millisecond)
+	rows, err := d.Query("SELECT id FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rows.Next() || rows.Scan(&id) != nil || id != 1 {
+		t.Errorf("Query with timeout got: %d %v", id, rows.Err())
+	}
+	rows.Close()
+
+	result.delay = time.Second
+	start := time.Now()
+	if err := d.GetMany("SELECT id FROM users", &ids); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetMany with timeout got: %v", err)
+	}
+	if _, err := d.Query("SELECT id FROM users"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Query with timeout got: %v", err)
+	}
+	if err := d.QueryRow("SELECT id FROM users").Scan(&id); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("QueryRow with timeout got: %v", err)
+	}
+	if time.Since(start) >= time.Second {
+		t.Errorf("queries should be canceled by timeout")
+	}
+}
+
+func TestNewQueryContext(t *testing.T) {
+	d := newFakeDb(t, &fakeResult{})
+	// 未设置超时时间时，不创建需要释放的ctx
+	if ctx := d.newQueryContext(); ctx.Done() != nil {
+		t.Errorf("newQueryContext without timeout should be context.Background()")
+	}
+	// 设置了超时时间时，ctx由计时器在超时后释放
+	d.SetTimeout(10 * time.Millisecond)
+	ctx := d.newQueryContext()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("newQueryContext should be released after timeout")
+	}
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("newQueryContext got: %v", ctx.Err())
+	}
+}
+
+func TestQueryRowBindError(t *testing.T) {
+	result := &fakeResult{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}
+	d := newFakeDb(t, result)
+	d.SetDialect(GetDialect("postgres"))
+	var id int64
+	if err := d.QueryRow("SELECT id FROM users WHERE id IN ($1)", []int64{}).Scan(&id); !errors.Is(err, ErrEmptySlice) {
+		t.Errorf("QueryRow got: %v", err)
+	}
+	if err := d.QueryRow("SELECT id FROM users WHERE name = :name", map[string]interface{}{"age": 1}).Scan(&id); err == nil {
+		t.Errorf("QueryRow with missing named arg should fail")
+	}
+	if log := result.executed(); len(log) != 0 {
+		t.Errorf("QueryRow should not execute after bind error: %v", log)
+	}
+	if err := d.QueryRow("SELECT id FROM users WHERE id IN ($1)", []int64{1}).Scan(&id); err != nil || id != 1 {
+		t.Errorf("QueryRow got: %d %v", id, err)
+	}
+}